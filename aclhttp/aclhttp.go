@@ -0,0 +1,234 @@
+// Package aclhttp provides a net/http middleware checking the requests against a confide_acl policy.
+//
+// Example:
+//
+//	acl, err := confide_acl.NewService(configacl)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	authz := aclhttp.New(acl, aclhttp.WithUserID(func(r *http.Request) (int, error) {
+//		return sessionUserID(r)
+//	}))
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/products/", authz.Require("role:admin OR permission:products.get")(productsHandler))
+package aclhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cangkir13/confide_acl"
+	"github.com/cangkir13/confide_acl/subject"
+)
+
+var (
+	// ErrNoUserID is returned by the default UserIDFunc when the context of the request has no user ID,
+	// it is subject.ErrNoSubject.
+	ErrNoUserID = subject.ErrNoSubject
+	// ErrForbidden is passed to the forbidden handler when the policy is not satisfied.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// UserIDFunc returns the ID of the user sending the request.
+// an error means the request is not authenticated, it is answered by the unauthorized handler.
+type UserIDFunc func(r *http.Request) (int, error)
+
+// ModuleFunc returns the module of the request checked by the policy, the method is r.Method.
+type ModuleFunc func(r *http.Request) string
+
+// ErrorHandler writes the response of a request refused by the middleware.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware checks the requests against confide_acl policies, build it with New.
+type Middleware struct {
+	acl          confide_acl.ConfideACL
+	userID       UserIDFunc
+	module       ModuleFunc
+	unauthorized ErrorHandler
+	forbidden    ErrorHandler
+	failure      ErrorHandler
+}
+
+// Option configures a Middleware built by New.
+type Option func(*Middleware)
+
+// New creates a Middleware checking the policies with acl.
+// by default the user ID is read from the context of the request, see ContextWithUserID,
+// the module is the path of the request converted by the PathNormalizer of the service, and the refused requests get a plain text 401, 403 or 500.
+//
+// Parameters:
+// - acl: The confide_acl service.
+// - opts: The options of the middleware, WithUserID or WithSubject, WithModule, WithUnauthorized, WithForbidden and WithError.
+//
+// Returns:
+// - *Middleware: The middleware, use Require to protect a handler.
+func New(acl confide_acl.ConfideACL, opts ...Option) *Middleware {
+	m := &Middleware{
+		acl:          acl,
+		userID:       UserIDFromContext,
+		module:       PathModule,
+		unauthorized: statusHandler(http.StatusUnauthorized),
+		forbidden:    statusHandler(http.StatusForbidden),
+		failure:      statusHandler(http.StatusInternalServerError),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithUserID sets the function returning the ID of the user sending the request.
+func WithUserID(fn UserIDFunc) Option {
+	return func(m *Middleware) {
+		m.userID = fn
+	}
+}
+
+// WithSubject reads the user ID with a subject.Extractor, from the context and the headers of the request.
+func WithSubject(extractor subject.Extractor) Option {
+	return func(m *Middleware) {
+		m.userID = func(r *http.Request) (int, error) {
+			return extractor.Extract(r.Context(), subject.HeaderCarrier(r.Header))
+		}
+	}
+}
+
+// WithModule sets the function returning the module of the request, PathModule by default.
+func WithModule(fn ModuleFunc) Option {
+	return func(m *Middleware) {
+		m.module = fn
+	}
+}
+
+// WithUnauthorized sets the response of the requests without user, err is the error of the UserIDFunc.
+func WithUnauthorized(h ErrorHandler) Option {
+	return func(m *Middleware) {
+		m.unauthorized = h
+	}
+}
+
+// WithForbidden sets the response of the requests refused by the policy, err is ErrForbidden,
+// or ErrRouteNotMapped for the requests matching no route of Enforce.
+func WithForbidden(h ErrorHandler) Option {
+	return func(m *Middleware) {
+		m.forbidden = h
+	}
+}
+
+// WithError sets the response of the requests that could not be checked, err is the error of PolicyACL,
+// confide_acl.ErrInvalidPolicy for an invalid policy or confide_acl.ErrStorage.
+func WithError(h ErrorHandler) Option {
+	return func(m *Middleware) {
+		m.failure = h
+	}
+}
+
+// Require returns a middleware letting through the requests satisfying the policy, see confide_acl.ConfideACL.PolicyACL
+// for the syntax. the policy is checked for the module of the request and r.Method.
+//
+// Parameters:
+// - policy: The policy expression, like "role:admin OR permission:products.get".
+//
+// Returns:
+// - func(http.Handler) http.Handler: The middleware.
+func (m *Middleware) Require(policy string) func(http.Handler) http.Handler {
+	return m.require(policy, m.module)
+}
+
+// RequireModule is Require with a fixed module, for the routes whose path does not name the module.
+//
+// Parameters:
+// - module: The module checked by the policy, like "products".
+// - policy: The policy expression.
+//
+// Returns:
+// - func(http.Handler) http.Handler: The middleware.
+func (m *Middleware) RequireModule(module, policy string) func(http.Handler) http.Handler {
+	return m.require(policy, func(*http.Request) string { return module })
+}
+
+func (m *Middleware) require(policy string, module ModuleFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serve(w, r, next, policy, module(r))
+		})
+	}
+}
+
+// Enforce returns a middleware checking every request against the policy of its route in table,
+// the module is the Module of the route. the requests matching no route are refused with ErrRouteNotMapped,
+// including the methods not declared for a path and the paths http.ServeMux would redirect, see RouteTable.Match.
+// the public routes are let through without user.
+//
+// Parameters:
+// - table: The route table, see NewRouteTable and LoadRoutes.
+//
+// Returns:
+// - func(http.Handler) http.Handler: The middleware.
+func (m *Middleware) Enforce(table *RouteTable) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := table.Match(r)
+			if !ok {
+				m.forbidden(w, r, ErrRouteNotMapped)
+				return
+			}
+			if route.Public {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m.serve(w, r, next, route.Policy, route.module())
+		})
+	}
+}
+
+// serve checks the policy for the user of the request and calls next when it is satisfied.
+func (m *Middleware) serve(w http.ResponseWriter, r *http.Request, next http.Handler, policy, module string) {
+	userID, err := m.userID(r)
+	if err != nil {
+		m.unauthorized(w, r, err)
+		return
+	}
+
+	allowed, err := m.acl.PolicyACL(r.Context(), userID, policy, module, r.Method)
+	if err != nil {
+		m.failure(w, r, err)
+		return
+	}
+	if !allowed {
+		m.forbidden(w, r, ErrForbidden)
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// ContextWithUserID returns a copy of ctx carrying the user ID read by the default UserIDFunc,
+// call it in the authentication middleware running before Require. it is subject.NewContext.
+func ContextWithUserID(ctx context.Context, userID int) context.Context {
+	return subject.NewContext(ctx, userID)
+}
+
+// UserIDFromContext returns the user ID set by ContextWithUserID, ErrNoUserID if there is none.
+// it is the default UserIDFunc.
+func UserIDFromContext(r *http.Request) (int, error) {
+	return subject.FromContext.Extract(r.Context(), subject.HeaderCarrier(r.Header))
+}
+
+// PathModule returns the path of the request as module, it is the default ModuleFunc.
+// the service converts it with confide_acl.ConfigACL.PathNormalizer: GET /products and GET /products/17 are both
+// checked against "products.get", and GET /api/v1/products/17 as well with the "/api" prefix and StripVersion set.
+func PathModule(r *http.Request) string {
+	return r.URL.Path
+}
+
+// statusHandler answers with the status code and its text.
+func statusHandler(status int) ErrorHandler {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, http.StatusText(status), status)
+	}
+}
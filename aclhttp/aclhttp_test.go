@@ -0,0 +1,175 @@
+package aclhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cangkir13/confide_acl"
+	"github.com/cangkir13/confide_acl/aclhttp"
+	"github.com/cangkir13/confide_acl/repository/memory"
+	"github.com/cangkir13/confide_acl/subject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newACL returns a service where user 1 is an editor allowed "products.get" and "articles.publish"
+func newACL(t *testing.T) confide_acl.ConfideACL {
+	ctx := context.Background()
+	acl, err := confide_acl.NewService(confide_acl.ConfigACL{Store: memory.New(), DisableSuperAdmin: true})
+	require.NoError(t, err)
+
+	require.NoError(t, acl.AddRole(ctx, "editor"))
+	require.NoError(t, acl.AddPermission(ctx, "products.get"))
+	require.NoError(t, acl.AddPermission(ctx, "articles.publish"))
+	require.NoError(t, acl.AssignPermissionToRole(ctx, "editor", []string{"products.get", "articles.publish"}))
+	require.NoError(t, acl.AssignUserToRole(ctx, 1, "editor"))
+	return acl
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+func TestRequire(t *testing.T) {
+	authz := aclhttp.New(newACL(t))
+	handler := authz.Require("role:editor")(okHandler)
+
+	tests := []struct {
+		name     string
+		method   string
+		userID   *int
+		expected int
+	}{
+		{name: "allowed", method: http.MethodGet, userID: intPtr(1), expected: http.StatusOK},
+		{name: "method not granted", method: http.MethodDelete, userID: intPtr(1), expected: http.StatusForbidden},
+		{name: "user without role", method: http.MethodGet, userID: intPtr(2), expected: http.StatusForbidden},
+		{name: "no user", method: http.MethodGet, expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/products/17", nil)
+			if tt.userID != nil {
+				r = r.WithContext(aclhttp.ContextWithUserID(r.Context(), *tt.userID))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+func TestPathModule(t *testing.T) {
+	assert.Equal(t, "/api/v1/products/17", aclhttp.PathModule(httptest.NewRequest(http.MethodGet, "/api/v1/products/17?expand=1", nil)))
+
+	// the path is converted by the PathNormalizer of the service
+	ctx := context.Background()
+	acl, err := confide_acl.NewService(confide_acl.ConfigACL{
+		Store:             memory.New(),
+		DisableSuperAdmin: true,
+		PathNormalizer:    &confide_acl.PathNormalizer{Prefixes: []string{"/api"}, StripVersion: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, acl.AddRole(ctx, "editor"))
+	require.NoError(t, acl.AddPermission(ctx, "products.get"))
+	require.NoError(t, acl.AssignPermissionToRole(ctx, "editor", []string{"products.get"}))
+	require.NoError(t, acl.AssignUserToRole(ctx, 1, "editor"))
+
+	authz := aclhttp.New(acl, aclhttp.WithUserID(func(r *http.Request) (int, error) {
+		return 1, nil
+	}))
+	handler := authz.Require("role:editor")(okHandler)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{path: "/api/v1/products/17", expected: http.StatusOK},
+		{path: "/api/v2/products", expected: http.StatusOK},
+		{path: "/api/v1/products/17/reviews", expected: http.StatusForbidden},
+		{path: "/api/v1/orders/17", expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.expected, w.Code, tt.path)
+	}
+}
+
+func TestRequireModule(t *testing.T) {
+	authz := aclhttp.New(newACL(t), aclhttp.WithUserID(func(r *http.Request) (int, error) {
+		return 1, nil
+	}))
+	handler := authz.RequireModule("articles", "role:editor")(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUBLISH", "/articles/12/publish", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
+
+func TestRequireResponses(t *testing.T) {
+	var refused error
+	respond := func(status int) aclhttp.ErrorHandler {
+		return func(w http.ResponseWriter, r *http.Request, err error) {
+			refused = err
+			w.WriteHeader(status)
+		}
+	}
+
+	authz := aclhttp.New(newACL(t),
+		aclhttp.WithUserID(func(r *http.Request) (int, error) {
+			if r.Header.Get("X-User") == "" {
+				return 0, aclhttp.ErrNoUserID
+			}
+			return 2, nil
+		}),
+		aclhttp.WithModule(func(r *http.Request) string { return "products" }),
+		aclhttp.WithUnauthorized(respond(http.StatusTeapot)),
+		aclhttp.WithForbidden(respond(http.StatusNotFound)),
+		aclhttp.WithError(respond(http.StatusBadGateway)),
+	)
+
+	w := httptest.NewRecorder()
+	authz.Require("role:editor")(okHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.ErrorIs(t, refused, aclhttp.ErrNoUserID)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User", "2")
+
+	w = httptest.NewRecorder()
+	authz.Require("role:editor")(okHandler).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.True(t, errors.Is(refused, aclhttp.ErrForbidden))
+
+	w = httptest.NewRecorder()
+	authz.Require("role:editor AND")(okHandler).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.ErrorIs(t, refused, confide_acl.ErrInvalidPolicy)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestWithSubject(t *testing.T) {
+	authz := aclhttp.New(newACL(t), aclhttp.WithSubject(subject.Header("X-User-ID")))
+	handler := authz.Require("role:editor")(okHandler)
+
+	r := httptest.NewRequest(http.MethodGet, "/products", nil)
+	r.Header.Set("X-User-ID", "1")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
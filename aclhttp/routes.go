@@ -0,0 +1,194 @@
+package aclhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrRouteNotMapped is passed to the forbidden handler by Enforce for the requests matching no route,
+	// it matches ErrForbidden.
+	ErrRouteNotMapped = fmt.Errorf("%w: route not mapped", ErrForbidden)
+	// ErrInvalidRoute is returned when a route of a route table can't be registered.
+	ErrInvalidRoute = errors.New("invalid route")
+)
+
+// Route maps a path pattern and an HTTP method to the policy required to access it.
+type Route struct {
+	// Pattern is a path pattern of http.ServeMux, like "/api/v1/products/{id}" or "/static/".
+	// a host can prefix the path, the method is set with Method
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Method is the HTTP method of the route, every method when empty. GET also matches HEAD
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	// Policy is the policy expression required to access the route, see confide_acl.ConfideACL.PolicyACL
+	Policy string `json:"policy,omitempty" yaml:"policy,omitempty"`
+	// Module is the module checked by the policy, the path of Pattern converted by the PathNormalizer
+	// of the service when empty, "products" for "/products/{id}"
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+	// Public routes are not checked, no user is required
+	Public bool `json:"public,omitempty" yaml:"public,omitempty"`
+}
+
+// RouteTable is a central table of the routes of an application and their policies, build it with
+// NewRouteTable or LoadRoutes and enforce it with Middleware.Enforce.
+// the routes are matched with the precedence rules of http.ServeMux, the most specific pattern wins.
+type RouteTable struct {
+	routes []Route
+	mux    *http.ServeMux
+}
+
+// routeFile is the format of the JSON and YAML files read by LoadRoutes
+type routeFile struct {
+	Routes []Route `json:"routes" yaml:"routes"`
+}
+
+// routeHandler marks the handler of a route in the mux of a RouteTable, it is the index of the route.
+type routeHandler int
+
+func (routeHandler) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// NewRouteTable creates a RouteTable from routes declared in Go.
+//
+// Parameters:
+// - routes: The routes of the table.
+//
+// Returns:
+// - *RouteTable: The route table.
+// - error: ErrInvalidRoute if a route has an invalid or a conflicting pattern, or no policy, otherwise nil.
+func NewRouteTable(routes ...Route) (*RouteTable, error) {
+	table := &RouteTable{routes: routes, mux: http.NewServeMux()}
+
+	for i, route := range routes {
+		if err := table.register(i, route); err != nil {
+			return nil, err
+		}
+	}
+
+	return table, nil
+}
+
+// LoadRoutes reads a RouteTable from a JSON file, or a YAML file with the .yaml or .yml extension.
+//
+// Example of YAML file:
+//
+//	routes:
+//	  - pattern: /api/v1/products/{id}
+//	    method: GET
+//	    policy: "role:admin OR permission:products.get"
+//	    module: products
+//	  - pattern: /healthz
+//	    public: true
+//
+// Parameters:
+// - path: The path of the file.
+//
+// Returns:
+// - *RouteTable: The route table.
+// - error: An error if the file can't be read or decoded, ErrInvalidRoute for an invalid route, otherwise nil.
+func LoadRoutes(path string) (*RouteTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseRoutesYAML(data)
+	default:
+		return ParseRoutesJSON(data)
+	}
+}
+
+// ParseRoutesJSON reads a RouteTable from JSON, see LoadRoutes for the format.
+func ParseRoutesJSON(data []byte) (*RouteTable, error) {
+	var file routeFile
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode routes: %w", err)
+	}
+
+	return NewRouteTable(file.Routes...)
+}
+
+// ParseRoutesYAML reads a RouteTable from YAML, see LoadRoutes for the format.
+func ParseRoutesYAML(data []byte) (*RouteTable, error) {
+	var file routeFile
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode routes: %w", err)
+	}
+
+	return NewRouteTable(file.Routes...)
+}
+
+// Routes returns the routes of the table.
+func (t *RouteTable) Routes() []Route {
+	return append([]Route(nil), t.routes...)
+}
+
+// Match returns the route of a request, false when no route matches.
+// only the exact matches count: the trailing-slash and clean-path redirects of http.ServeMux and the
+// methods not declared for a path are not matched, Enforce refuses them with ErrRouteNotMapped
+// instead of answering with the redirect or 405 of the mux, so the table does not reveal its routes.
+func (t *RouteTable) Match(r *http.Request) (Route, bool) {
+	handler, _ := t.mux.Handler(r)
+
+	index, ok := handler.(routeHandler)
+	if !ok {
+		return Route{}, false
+	}
+	return t.routes[index], true
+}
+
+// register adds the route i to the mux, http.ServeMux panics on invalid and conflicting patterns.
+func (t *RouteTable) register(i int, route Route) (err error) {
+	if route.Pattern == "" {
+		return fmt.Errorf("%w: route %d has no pattern", ErrInvalidRoute, i)
+	}
+	if !route.Public && strings.TrimSpace(route.Policy) == "" {
+		return fmt.Errorf("%w: %q has no policy, set public to skip the check", ErrInvalidRoute, route.Pattern)
+	}
+	if strings.ContainsAny(route.Method, " \t/") {
+		return fmt.Errorf("%w: %q has an invalid method %q", ErrInvalidRoute, route.Pattern, route.Method)
+	}
+
+	pattern := route.Pattern
+	if route.Method != "" {
+		pattern = strings.ToUpper(route.Method) + " " + pattern
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidRoute, recovered)
+		}
+	}()
+
+	t.mux.Handle(pattern, routeHandler(i))
+	return nil
+}
+
+// module returns the module checked for a route, the path of the pattern without its host by default.
+// the service converts it with its PathNormalizer, the "{name}" segments are path parameters.
+func (route Route) module() string {
+	if route.Module != "" {
+		return route.Module
+	}
+
+	path := route.Pattern
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i:]
+	}
+	return path
+}
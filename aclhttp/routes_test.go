@@ -0,0 +1,202 @@
+package aclhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cangkir13/confide_acl"
+	"github.com/cangkir13/confide_acl/aclhttp"
+	"github.com/cangkir13/confide_acl/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const routesYAML = `routes:
+  - pattern: /products
+    method: GET
+    policy: "role:editor"
+  - pattern: /api/v1/products/{id}
+    method: DELETE
+    policy: "role:editor"
+    module: products
+  - pattern: /healthz
+    public: true
+`
+
+const routesJSON = `{"routes": [
+  {"pattern": "/products", "method": "GET", "policy": "role:editor"},
+  {"pattern": "/api/v1/products/{id}", "method": "DELETE", "policy": "role:editor", "module": "products"},
+  {"pattern": "/healthz", "public": true}
+]}`
+
+func TestParseRoutes(t *testing.T) {
+	fromYAML, err := aclhttp.ParseRoutesYAML([]byte(routesYAML))
+	require.NoError(t, err)
+
+	fromJSON, err := aclhttp.ParseRoutesJSON([]byte(routesJSON))
+	require.NoError(t, err)
+
+	assert.Equal(t, fromYAML.Routes(), fromJSON.Routes())
+	assert.Equal(t, aclhttp.Route{
+		Pattern: "/api/v1/products/{id}",
+		Method:  "DELETE",
+		Policy:  "role:editor",
+		Module:  "products",
+	}, fromYAML.Routes()[1])
+
+	_, err = aclhttp.ParseRoutesYAML([]byte("routes:\n  - pattern: /x\n    polcy: role:admin\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadRoutes(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{"routes.yaml": routesYAML, "routes.json": routesJSON} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		table, err := aclhttp.LoadRoutes(path)
+		require.NoError(t, err, name)
+		assert.Len(t, table.Routes(), 3, name)
+	}
+
+	_, err := aclhttp.LoadRoutes(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestNewRouteTableInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []aclhttp.Route
+	}{
+		{name: "no pattern", routes: []aclhttp.Route{{Policy: "role:admin"}}},
+		{name: "no policy", routes: []aclhttp.Route{{Pattern: "/products"}}},
+		{name: "invalid pattern", routes: []aclhttp.Route{{Pattern: "/products/{id", Policy: "role:admin"}}},
+		{name: "invalid method", routes: []aclhttp.Route{{Pattern: "/products", Method: "GET /x", Policy: "role:admin"}}},
+		{name: "conflict", routes: []aclhttp.Route{
+			{Pattern: "/products/{id}", Method: "GET", Policy: "role:admin"},
+			{Pattern: "/products/{name}", Method: "GET", Policy: "role:admin"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := aclhttp.NewRouteTable(tt.routes...)
+			assert.ErrorIs(t, err, aclhttp.ErrInvalidRoute)
+		})
+	}
+}
+
+func TestRouteTableMatch(t *testing.T) {
+	table, err := aclhttp.NewRouteTable(
+		aclhttp.Route{Pattern: "/products/", Policy: "role:viewer"},
+		aclhttp.Route{Pattern: "/products/{id}", Method: http.MethodGet, Policy: "role:editor"},
+	)
+	require.NoError(t, err)
+
+	// the most specific pattern wins
+	route, ok := table.Match(httptest.NewRequest(http.MethodGet, "/products/12", nil))
+	require.True(t, ok)
+	assert.Equal(t, "role:editor", route.Policy)
+
+	route, ok = table.Match(httptest.NewRequest(http.MethodPost, "/products/12", nil))
+	require.True(t, ok)
+	assert.Equal(t, "role:viewer", route.Policy)
+
+	_, ok = table.Match(httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.False(t, ok)
+
+	// only the exact matches count, not the 405 or the redirects of http.ServeMux
+	methods, err := aclhttp.NewRouteTable(
+		aclhttp.Route{Pattern: "/orders/{id}", Method: http.MethodGet, Policy: "role:viewer"},
+		aclhttp.Route{Pattern: "/docs/", Policy: "role:viewer"},
+	)
+	require.NoError(t, err)
+
+	_, ok = methods.Match(httptest.NewRequest(http.MethodDelete, "/orders/12", nil))
+	assert.False(t, ok, "method not declared")
+
+	_, ok = methods.Match(httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.False(t, ok, "trailing-slash redirect")
+
+	_, ok = methods.Match(httptest.NewRequest(http.MethodGet, "/docs/../orders/12", nil))
+	assert.False(t, ok, "clean-path redirect")
+}
+
+func TestEnforce(t *testing.T) {
+	table, err := aclhttp.ParseRoutesYAML([]byte(routesYAML))
+	require.NoError(t, err)
+
+	authz := aclhttp.New(newACL(t))
+	handler := authz.Enforce(table)(okHandler)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		userID   *int
+		expected int
+	}{
+		{name: "allowed", method: http.MethodGet, path: "/products", userID: intPtr(1), expected: http.StatusOK},
+		{name: "path parameter", method: http.MethodDelete, path: "/api/v1/products/17", userID: intPtr(1), expected: http.StatusForbidden},
+		{name: "user without role", method: http.MethodGet, path: "/products", userID: intPtr(2), expected: http.StatusForbidden},
+		{name: "no user", method: http.MethodGet, path: "/products", expected: http.StatusUnauthorized},
+		{name: "public", method: http.MethodGet, path: "/healthz", expected: http.StatusOK},
+		{name: "unmapped method", method: http.MethodPost, path: "/products", userID: intPtr(1), expected: http.StatusForbidden},
+		{name: "unmapped path", method: http.MethodGet, path: "/orders", userID: intPtr(1), expected: http.StatusForbidden},
+		{name: "unmapped method without user", method: http.MethodPut, path: "/products", expected: http.StatusForbidden},
+		{name: "redirect", method: http.MethodGet, path: "/orders/../healthz", expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.userID != nil {
+				r = r.WithContext(aclhttp.ContextWithUserID(r.Context(), *tt.userID))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+func TestEnforceRouteModule(t *testing.T) {
+	ctx := context.Background()
+	acl, err := confide_acl.NewService(confide_acl.ConfigACL{
+		Store:             memory.New(),
+		DisableSuperAdmin: true,
+		PathNormalizer:    &confide_acl.PathNormalizer{Prefixes: []string{"/api"}, StripVersion: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, acl.AddRole(ctx, "editor"))
+	require.NoError(t, acl.AddPermission(ctx, "products.get"))
+	require.NoError(t, acl.AssignPermissionToRole(ctx, "editor", []string{"products.get"}))
+	require.NoError(t, acl.AssignUserToRole(ctx, 1, "editor"))
+
+	// the module is the pattern normalized by the service, "products"
+	table, err := aclhttp.NewRouteTable(
+		aclhttp.Route{Pattern: "/api/v1/products/{id}", Method: http.MethodGet, Policy: "role:editor"},
+		aclhttp.Route{Pattern: "/api/v1/products/{id}/reviews", Policy: "role:editor"},
+	)
+	require.NoError(t, err)
+
+	authz := aclhttp.New(acl, aclhttp.WithUserID(func(r *http.Request) (int, error) {
+		return 1, nil
+	}))
+	handler := authz.Enforce(table)(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/17", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// "products.reviews" is not granted by products.get
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/17/reviews", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -8,6 +8,7 @@ package confide_acl
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/cangkir13/confide_acl/repository"
 )
@@ -15,33 +16,130 @@ import (
 // #Table default table for user
 var defaultTable string = "users"
 
+// #SuperAdmin default roles bypassing every check in PolicyACL
+var defaultSuperAdminRoles = []string{"Superadmin", "Admin"}
+
+// #VerbGroups default groups of HTTP methods granted by a permission like "products.read", "*" is every method
+var defaultVerbGroups = map[string][]string{
+	"read":   {"GET", "HEAD", "OPTIONS"},
+	"write":  {"POST", "PUT", "PATCH"},
+	"manage": {wildcard},
+}
+
 // config acl service struct
 type ConfigACL struct {
 	Database     *sql.DB
 	TableAccount string // setup default table if not set it's changes to defaultTable
+	// Tables maps the tables and columns to an existing schema, with an optional prefix for the confide_acl tables.
+	// Tables.Account replaces TableAccount when set. the embedded migrations use the default names,
+	// create the mapped tables yourself
+	Tables repository.Tables
+	// Dialect of Database, repository.DialectMySQL, repository.DialectPostgres or repository.DialectSQLite. default is MySQL
+	Dialect repository.Dialect
+
+	// Store replaces the SQL storage built from Database, TableAccount, Tables and Dialect, they are ignored when it is set.
+	// see repository.RepositoryService for the contract of an implementation
+	Store repository.RepositoryService
+
+	// SuperAdminRoles are the role names that bypass every check in PolicyACL.
+	// if not set it's changes to defaultSuperAdminRoles ("Superadmin" and "Admin")
+	SuperAdminRoles []string
+	// DisableSuperAdmin turns the super-admin bypass off, every request is checked against the policy
+	DisableSuperAdmin bool
+
+	// PathNormalizer converts the modules given as URL paths to the modules of the permission names,
+	// "/api/v1/products/17" to "products" for example. if not set the paths are converted by a zero PathNormalizer,
+	// "/products/17/reviews" to "products.reviews"
+	PathNormalizer *PathNormalizer
+
+	// VerbGroups are the names usable as method of a permission to grant several methods, "products.read"
+	// grants GET, HEAD and OPTIONS on products. "*" in a group grants every method.
+	// if not set it's changes to defaultVerbGroups ("read", "write" and "manage")
+	VerbGroups map[string][]string
+	// DisableVerbGroups turns the verb groups off, the method of a permission only matches the same method
+	DisableVerbGroups bool
 }
 
 // ConfideACL interface
+// the methods return *Error values, match them with errors.Is(err, ErrNotFound), ErrAlreadyExists,
+// ErrInvalidPolicy, ErrInvalidArgument and ErrStorage, or with the sentinels of the repository package.
 type ConfideACL interface {
 	AddRole(ctx context.Context, name string) error
 	AddPermission(ctx context.Context, name string) error
-	AssignPermissionToRole(ctx context.Context, role string, permissions []string) error
+	DeleteRole(ctx context.Context, name string) error
+	DeletePermission(ctx context.Context, name string) error
+	RenameRole(ctx context.Context, name, newName string) error
+	RenamePermission(ctx context.Context, name, newName string) error
+	AssignPermissionToRole(ctx context.Context, role string, permissions []string, opts ...AssignOption) error
 	AssignUserToRole(ctx context.Context, userid uint, role string) error
+	RevokePermissionFromRole(ctx context.Context, role string, permissions []string) error
+	RemoveUserFromRole(ctx context.Context, userid uint, role string) error
+	GivePermissionToUser(ctx context.Context, userid uint, permissions []string) error
+	RevokePermissionFromUser(ctx context.Context, userid uint, permissions []string) error
+	ListRoles(ctx context.Context, page repository.Pagination) ([]repository.Role, error)
+	ListPermissions(ctx context.Context, page repository.Pagination) ([]repository.Permission, error)
+	GetRolePermissions(ctx context.Context, role string, page repository.Pagination) ([]repository.Permission, error)
+	GetUserRoles(ctx context.Context, userid uint, page repository.Pagination) ([]repository.Role, error)
+	GetUserPermissions(ctx context.Context, userid uint, page repository.Pagination) ([]repository.Permission, error)
+	GetUserDirectPermissions(ctx context.Context, userid uint, page repository.Pagination) ([]repository.Permission, error)
+	ListUsersWithRole(ctx context.Context, role string, page repository.Pagination) ([]uint, error)
+	SetRoleParents(ctx context.Context, role string, parents []string) error
+	DenyPermissionToRole(ctx context.Context, role string, permissions []string) error
+	RemoveDenyFromRole(ctx context.Context, role string, permissions []string) error
+	DenyPermissionToUser(ctx context.Context, userid uint, permissions []string) error
+	RemoveDenyFromUser(ctx context.Context, userid uint, permissions []string) error
 	PolicyACL(ctx context.Context, userid int, rolePermission, module, method string) (bool, error)
+	Explain(ctx context.Context, userid int, rolePermission, module, method string) (Decision, error)
 }
 
 // NewService creates a new instance of the Service struct.
 //
 // Parameters:
-// - conf: ConfigACL struct containing the database connection and default table account.
+// - conf: ConfigACL struct containing the database connection and default table account, or a custom Store.
 //
 // Returns:
 // - a pointer to the Service struct.
-func NewService(conf ConfigACL) ConfideACL {
+// - error: repository.ErrUnsupportedDialect or repository.ErrInvalidIdentifier if the SQL configuration is invalid.
+func NewService(conf ConfigACL) (ConfideACL, error) {
 	if conf.TableAccount == "" {
 		conf.TableAccount = defaultTable
 	}
-	return &service{
-		repo: repository.NewSQL(conf.Database, conf.TableAccount),
+
+	superAdminRoles := conf.SuperAdminRoles
+	if len(superAdminRoles) == 0 {
+		superAdminRoles = defaultSuperAdminRoles
+	}
+	if conf.DisableSuperAdmin {
+		superAdminRoles = nil
 	}
+
+	paths := conf.PathNormalizer
+	if paths == nil {
+		paths = &PathNormalizer{}
+	}
+
+	verbs := conf.VerbGroups
+	if len(verbs) == 0 {
+		verbs = defaultVerbGroups
+	}
+	if conf.DisableVerbGroups {
+		verbs = nil
+	}
+
+	store := conf.Store
+	if store == nil {
+		sqlStore := repository.NewSQL(conf.Database, conf.TableAccount,
+			repository.WithDialect(conf.Dialect), repository.WithTables(conf.Tables))
+		if err := sqlStore.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid ACL configuration: %w", err)
+		}
+		store = &sqlStore
+	}
+
+	return &service{
+		repo:            store,
+		superAdminRoles: superAdminRoles,
+		paths:           paths,
+		verbs:           newVerbGroups(verbs),
+	}, nil
 }
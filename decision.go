@@ -0,0 +1,68 @@
+package confide_acl
+
+// Reasons of a Decision.
+const (
+	// ReasonSuperAdmin the user holds one of the configured super-admin roles, the policy was not evaluated
+	ReasonSuperAdmin = "super_admin"
+	// ReasonGranted the policy is satisfied and no deny rule matches
+	ReasonGranted = "granted"
+	// ReasonDenied the policy is satisfied but a permission denied to the user or to one of the user's roles matches
+	ReasonDenied = "denied"
+	// ReasonNotGranted the policy is not satisfied
+	ReasonNotGranted = "not_granted"
+	// ReasonInvalidPolicy the policy could not be parsed
+	ReasonInvalidPolicy = "invalid_policy"
+)
+
+// Sources of a deny rule in a Decision.
+const (
+	DeniedByUser = "user"
+	DeniedByRole = "role"
+)
+
+// Decision explains the outcome of PolicyACL, it is built from the same steps and returned by Explain.
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	// Policy is the parsed policy with explicit operators and parentheses, empty if it could not be parsed
+	Policy string `json:"policy,omitempty"`
+	// Module and Method are the lower-cased module and method the policy was evaluated against
+	Module string `json:"module"`
+	Method string `json:"method"`
+
+	// SuperAdminRole is the configured bypass role held by the user
+	SuperAdminRole string `json:"super_admin_role,omitempty"`
+
+	// MatchedRule is the clause of the policy that granted access, Role and Permission the role
+	// and the stored permission that satisfied it. they are empty when the policy is only satisfied
+	// through NOT clauses
+	MatchedRule string `json:"matched_rule,omitempty"`
+	Role        string `json:"role,omitempty"`
+	Permission  string `json:"permission,omitempty"`
+
+	// DeniedPermission is the deny rule overriding the grants, DeniedBy is DeniedByUser or DeniedByRole
+	DeniedPermission string `json:"denied_permission,omitempty"`
+	DeniedBy         string `json:"denied_by,omitempty"`
+
+	// Clauses are the clauses of the policy in evaluation order, clauses skipped
+	// by the short-circuit of AND and OR are not listed
+	Clauses []ClauseResult `json:"clauses,omitempty"`
+}
+
+// ClauseResult is the result of a single "role:" or "permission:" clause of the policy.
+type ClauseResult struct {
+	Clause    string `json:"clause"`
+	Satisfied bool   `json:"satisfied"`
+	// Role is the role of the clause held by the user that granted module.method, empty for a "permission:" clause
+	Role string `json:"role,omitempty"`
+	// Permission is the stored permission that granted module.method, wildcards included
+	Permission string `json:"permission,omitempty"`
+}
+
+// deniedPermission is a deny rule matching the requested module.method.
+type deniedPermission struct {
+	// permission is the name of the denied permission, wildcards included
+	permission string
+	// source is DeniedByUser or DeniedByRole
+	source string
+}
@@ -0,0 +1,126 @@
+package confide_acl
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cangkir13/confide_acl/repository"
+)
+
+// Error categories returned by the service, match them with errors.Is.
+// the errors of the service are *Error values carrying the kind and the name of the entity,
+// the underlying error stays reachable with errors.Is and errors.As,
+// errors.Is(err, repository.ErrRoleNotFound) keeps working for example.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrInvalidPolicy   = errors.New("invalid policy")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrStorage         = errors.New("storage error")
+)
+
+// Kind is the kind of entity an Error is about.
+type Kind string
+
+const (
+	KindRole             Kind = "role"
+	KindPermission       Kind = "permission"
+	KindRolePermission   Kind = "role permission"
+	KindUserRole         Kind = "user role"
+	KindUserPermission   Kind = "user permission"
+	KindDeniedPermission Kind = "denied permission"
+	KindPolicy           Kind = "policy"
+)
+
+// Error is an error of the service about an entity.
+//
+// Example:
+//
+//	var aclErr *confide_acl.Error
+//	if errors.As(err, &aclErr) && errors.Is(err, confide_acl.ErrNotFound) {
+//		log.Printf("%s %q does not exist", aclErr.Kind, aclErr.Name)
+//	}
+type Error struct {
+	// Code is the category of the error: ErrNotFound, ErrAlreadyExists, ErrInvalidPolicy, ErrInvalidArgument or ErrStorage
+	Code error
+	// Kind is the kind of entity, KindRole, KindPermission, ...
+	Kind Kind
+	// Name is the name of the entity, the names are separated by ", " for a list of entities.
+	// for ErrNotFound it lists the missing names only. it is empty when the error is not about a named entity
+	Name string
+	// Err is the underlying error, a sentinel of the repository package or the error of the driver
+	Err error
+}
+
+// Error returns the message of the error, like `role "editor" not found`.
+func (e *Error) Error() string {
+	subject := string(e.Kind)
+	if e.Name != "" {
+		subject += " " + strconv.Quote(e.Name)
+	}
+
+	switch {
+	case e.Code == ErrNotFound || e.Code == ErrAlreadyExists:
+		return subject + " " + e.Code.Error()
+	case e.Err == nil:
+		return e.Code.Error() + ": " + subject
+	default:
+		return e.Code.Error() + ": " + subject + ": " + e.Err.Error()
+	}
+}
+
+// Is reports whether target is the category of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Code
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorCodes maps the sentinel errors of the repository package and of the service to the categories of Error.
+var errorCodes = map[error]error{
+	ErrRoleHierarchyCycle:                   ErrInvalidArgument,
+	repository.ErrRoleNotFound:              ErrNotFound,
+	repository.ErrPermissionNotFound:        ErrNotFound,
+	repository.ErrPermissionNotAssigned:     ErrNotFound,
+	repository.ErrUserRoleNotAssigned:       ErrNotFound,
+	repository.ErrUserPermissionNotAssigned: ErrNotFound,
+	repository.ErrPermissionNotDenied:       ErrNotFound,
+	repository.ErrDuplicateRole:             ErrAlreadyExists,
+	repository.ErrDuplicatePermission:       ErrAlreadyExists,
+	repository.ErrDuplicateRolePermission:   ErrAlreadyExists,
+	repository.ErrDuplicateUserRole:         ErrAlreadyExists,
+	repository.ErrDuplicateUserPermission:   ErrAlreadyExists,
+	repository.ErrDuplicateDeniedPermission: ErrAlreadyExists,
+}
+
+// newError converts an error of the repository into an *Error about the entity.
+// the sentinels of the repository get their category, the other errors are ErrStorage.
+// nil and an *Error are returned as is.
+func newError(err error, kind Kind, name string) error {
+	if err == nil {
+		return err
+	}
+
+	var aclErr *Error
+	if errors.As(err, &aclErr) {
+		return err
+	}
+
+	// the lookups by name tell which names are missing
+	var notFound *repository.NotFoundError
+	if errors.As(err, &notFound) && len(notFound.Names) > 0 {
+		name = strings.Join(notFound.Names, ", ")
+	}
+
+	for sentinel, code := range errorCodes {
+		if errors.Is(err, sentinel) {
+			return &Error{Code: code, Kind: kind, Name: name, Err: err}
+		}
+	}
+
+	return &Error{Code: ErrStorage, Kind: kind, Name: name, Err: err}
+}
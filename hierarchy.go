@@ -0,0 +1,99 @@
+package confide_acl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cangkir13/confide_acl/repository"
+)
+
+// ErrRoleHierarchyCycle is returned when a role would inherit from itself through its parents.
+var ErrRoleHierarchyCycle = errors.New("role hierarchy cycle")
+
+// roleParents indexes the role_has_roles relations by role ID.
+func roleParents(hierarchy []repository.RoleHasRole) map[uint][]uint {
+	parents := make(map[uint][]uint)
+	for _, relation := range hierarchy {
+		parents[relation.RoleID] = append(parents[relation.RoleID], relation.ParentRoleID)
+	}
+	return parents
+}
+
+// inheritedRoles returns the given roles followed by every role they inherit from, transitively.
+// each role appears once, so a cycle in the stored hierarchy can't loop forever.
+func inheritedRoles(roleIDs []uint, parents map[uint][]uint) []uint {
+	var result []uint
+	seen := make(map[uint]bool)
+
+	queue := append([]uint(nil), roleIDs...)
+	for len(queue) > 0 {
+		roleID := queue[0]
+		queue = queue[1:]
+
+		if seen[roleID] {
+			continue
+		}
+		seen[roleID] = true
+		result = append(result, roleID)
+		queue = append(queue, parents[roleID]...)
+	}
+
+	return result
+}
+
+// createsRoleCycle reports whether giving parentIDs to roleID makes roleID inherit from itself.
+func createsRoleCycle(roleID uint, parentIDs []uint, parents map[uint][]uint) bool {
+	for _, inherited := range inheritedRoles(parentIDs, parents) {
+		if inherited == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// userRoleHierarchy returns the IDs of the roles held by the user, directly or inherited,
+// together with the parents index used to resolve them.
+func (s *service) userRoleHierarchy(ctx context.Context, userID uint) ([]uint, map[uint][]uint, error) {
+	roles, err := s.repo.GetUserRoles(ctx, userID, repository.Pagination{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(roles) == 0 {
+		return nil, nil, nil
+	}
+
+	hierarchy, err := s.repo.GetRoleHierarchy(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roleIDs := make([]uint, len(roles))
+	for i, role := range roles {
+		roleIDs[i] = role.ID
+	}
+
+	parents := roleParents(hierarchy)
+	return inheritedRoles(roleIDs, parents), parents, nil
+}
+
+// userRoles is the role closure of a user, it is loaded once per policy evaluation and shared by
+// the clauses of the policy and the deny rules.
+type userRoles struct {
+	userID  uint
+	loaded  bool
+	ids     []uint
+	parents map[uint][]uint
+}
+
+// loadUserRoles returns the role closure of held, it is read from the storage on the first call only.
+func (s *service) loadUserRoles(ctx context.Context, held *userRoles) ([]uint, map[uint][]uint, error) {
+	if !held.loaded {
+		ids, parents, err := s.userRoleHierarchy(ctx, held.userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		held.ids, held.parents, held.loaded = ids, parents, true
+	}
+	return held.ids, held.parents, nil
+}
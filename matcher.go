@@ -0,0 +1,175 @@
+package confide_acl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cangkir13/confide_acl/repository"
+)
+
+// wildcard matches any module or any method in a stored permission name
+const wildcard = "*"
+
+// Precedence of the stored permissions matching a module.method, the most specific one wins:
+// "products.get" (exact) > "products.read" (a verb group of the module) > "products.*" (any method of the module) >
+// "*.get" (the method on any module) > "*.read" (a verb group on any module) > "*" (everything).
+const (
+	matchNone = iota
+	matchAny
+	matchAnyModuleGroup
+	matchAnyModule
+	matchAnyMethod
+	matchGroup
+	matchExact
+)
+
+// verbGroups maps the name of a verb group to the lower case methods it grants, "*" grants every method.
+type verbGroups map[string]map[string]bool
+
+// newVerbGroups lower cases the names and the methods of the verb groups of the configuration.
+func newVerbGroups(groups map[string][]string) verbGroups {
+	result := make(verbGroups, len(groups))
+	for name, methods := range groups {
+		set := make(map[string]bool, len(methods))
+		for _, method := range methods {
+			set[strings.ToLower(method)] = true
+		}
+		result[strings.ToLower(name)] = set
+	}
+	return result
+}
+
+// grants reports whether the permission method is a verb group granting method.
+func (g verbGroups) grants(group, method string) bool {
+	methods, ok := g[group]
+	return ok && (methods[method] || methods[wildcard])
+}
+
+// of returns the names of the verb groups granting method in name order.
+func (g verbGroups) of(method string) []string {
+	var names []string
+	for name := range g {
+		if g.grants(name, method) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitPermission splits a permission name into module and method at the last ".".
+// "*" is returned as "*", "*" so it matches every module and method.
+func splitPermission(name string) (string, string) {
+	if name == wildcard {
+		return wildcard, wildcard
+	}
+
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
+
+// matchPermission reports how specifically the stored permission name grants module.method,
+// matchNone is returned when it doesn't. the method of the permission can be one of the verb groups.
+func matchPermission(name, module, method string, groups verbGroups) int {
+	permissionModule, permissionMethod := splitPermission(name)
+
+	if permissionModule != module && permissionModule != wildcard {
+		return matchNone
+	}
+
+	group := false
+	if permissionMethod != method && permissionMethod != wildcard {
+		if !groups.grants(permissionMethod, method) {
+			return matchNone
+		}
+		group = true
+	}
+
+	switch {
+	case permissionModule != wildcard && group:
+		return matchGroup
+	case permissionModule != wildcard && permissionMethod != wildcard:
+		return matchExact
+	case permissionModule != wildcard:
+		return matchAnyMethod
+	case group:
+		return matchAnyModuleGroup
+	case permissionMethod != wildcard:
+		return matchAnyModule
+	default:
+		return matchAny
+	}
+}
+
+// bestPermissionMatch returns the most specific permission granting module.method.
+// on equal precedence the first permission in the list wins.
+func bestPermissionMatch(permissions []repository.Permission, module, method string, groups verbGroups) (repository.Permission, bool) {
+	var best repository.Permission
+	bestRank := matchNone
+
+	for _, permission := range permissions {
+		if rank := matchPermission(permission.Name, module, method, groups); rank > bestRank {
+			best, bestRank = permission, rank
+		}
+	}
+
+	return best, bestRank != matchNone
+}
+
+// coveredPermission reports whether one of permissions grants everything the permission name grants,
+// "*.delete" covers "products.delete" but "products.delete" doesn't cover "products.*".
+func coveredPermission(permissions []repository.Permission, name string, groups verbGroups) bool {
+	module, method := splitPermission(name)
+	for _, permission := range permissions {
+		if matchPermission(permission.Name, module, method, groups) != matchNone {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionCandidates expands the permission names of a policy with the wildcard and verb group
+// permissions covering them, "products.get" gives "products.get", "products.read", "products.*", "*.get",
+// "*.read" and "*" with the default verb groups.
+// the names not granting module.method are skipped, "orders.get" and "orders.read" give nothing for products.get,
+// so a wildcard or a verb group held by the user only satisfies a policy listing the checked module.
+// it is used to look up the stored permissions a user may hold for the policy.
+func permissionCandidates(names []string, module, method string, groups verbGroups) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+
+	for _, name := range names {
+		if matchPermission(name, module, method, groups) == matchNone {
+			continue
+		}
+
+		// the verb groups are the ones granting the checked method, the module of the name is the checked module
+		permissionModule, permissionMethod := splitPermission(name)
+		add(name)
+		if permissionModule != wildcard && permissionMethod != wildcard {
+			for _, group := range groups.of(method) {
+				add(module + "." + group)
+			}
+			add(module + "." + wildcard)
+		}
+		if permissionMethod != wildcard {
+			add(wildcard + "." + permissionMethod)
+			for _, group := range groups.of(method) {
+				add(wildcard + "." + group)
+			}
+		}
+		add(wildcard)
+	}
+
+	return candidates
+}
@@ -0,0 +1,123 @@
+package confide_acl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cangkir13/confide_acl/repository"
+)
+
+// Unit test for matchPermission
+func TestMatchPermission(t *testing.T) {
+	groups := newVerbGroups(defaultVerbGroups)
+
+	tests := []struct {
+		name     string
+		module   string
+		method   string
+		expected int
+	}{
+		{name: "products.get", module: "products", method: "get", expected: matchExact},
+		{name: "products.*", module: "products", method: "get", expected: matchAnyMethod},
+		{name: "*.get", module: "products", method: "get", expected: matchAnyModule},
+		{name: "*", module: "products", method: "get", expected: matchAny},
+		{name: "products.post", module: "products", method: "get", expected: matchNone},
+		{name: "orders.*", module: "products", method: "get", expected: matchNone},
+		{name: "*.post", module: "products", method: "get", expected: matchNone},
+		{name: "products.reviews.*", module: "products.reviews", method: "get", expected: matchAnyMethod},
+		{name: "products", module: "products", method: "get", expected: matchNone},
+		{name: "products.read", module: "products", method: "head", expected: matchGroup},
+		{name: "*.read", module: "products", method: "options", expected: matchAnyModuleGroup},
+		{name: "products.write", module: "products", method: "patch", expected: matchGroup},
+		{name: "products.manage", module: "products", method: "delete", expected: matchGroup},
+		{name: "products.read", module: "products", method: "post", expected: matchNone},
+		{name: "orders.read", module: "products", method: "get", expected: matchNone},
+		{name: "products.unknown", module: "products", method: "get", expected: matchNone},
+	}
+
+	for _, test := range tests {
+		if rank := matchPermission(test.name, test.module, test.method, groups); rank != test.expected {
+			t.Errorf("matchPermission(%s, %s, %s) returned %d, expected %d", test.name, test.module, test.method, rank, test.expected)
+		}
+	}
+}
+
+// Unit test for bestPermissionMatch
+func TestBestPermissionMatch(t *testing.T) {
+	permissions := []repository.Permission{
+		{ID: 1, Name: "*"},
+		{ID: 2, Name: "*.get"},
+		{ID: 3, Name: "products.*"},
+		{ID: 4, Name: "products.get"},
+	}
+
+	for i, expected := range []uint{4, 3, 2, 1} {
+		best, ok := bestPermissionMatch(permissions[:len(permissions)-i], "products", "get", nil)
+		if !ok || best.ID != expected {
+			t.Errorf("bestPermissionMatch(%v) returned %v, expected permission %d", permissions[:len(permissions)-i], best, expected)
+		}
+	}
+
+	if _, ok := bestPermissionMatch(permissions[2:], "orders", "post", nil); ok {
+		t.Errorf("bestPermissionMatch(%v) matched orders.post", permissions[2:])
+	}
+
+	grouped := []repository.Permission{
+		{ID: 1, Name: "*"},
+		{ID: 2, Name: "*.read"},
+		{ID: 3, Name: "*.get"},
+		{ID: 4, Name: "products.*"},
+		{ID: 5, Name: "products.read"},
+		{ID: 6, Name: "products.get"},
+	}
+	groups := newVerbGroups(defaultVerbGroups)
+
+	for i, expected := range []uint{6, 5, 4, 3, 2, 1} {
+		best, ok := bestPermissionMatch(grouped[:len(grouped)-i], "products", "get", groups)
+		if !ok || best.ID != expected {
+			t.Errorf("bestPermissionMatch(%v) returned %v, expected permission %d", grouped[:len(grouped)-i], best, expected)
+		}
+	}
+
+	if _, ok := bestPermissionMatch(grouped[1:3], "products", "get", nil); !ok {
+		t.Errorf("bestPermissionMatch(%v) without verb groups did not match *.get", grouped[1:3])
+	}
+	if _, ok := bestPermissionMatch(grouped[1:2], "products", "get", nil); ok {
+		t.Errorf("bestPermissionMatch(%v) without verb groups matched products.get", grouped[1:2])
+	}
+}
+
+// Unit test for permissionCandidates
+func TestPermissionCandidates(t *testing.T) {
+	groups := newVerbGroups(defaultVerbGroups)
+
+	tests := []struct {
+		input    []string
+		method   string
+		groups   verbGroups
+		expected []string
+	}{
+		{input: []string{"products.get"}, expected: []string{"products.get", "products.*", "*.get", "*"}},
+		{input: []string{"products.get"}, groups: groups, expected: []string{"products.get", "products.manage", "products.read", "products.*", "*.get", "*.manage", "*.read", "*"}},
+		{input: []string{"products.delete"}, method: "delete", groups: groups, expected: []string{"products.delete", "products.manage", "products.*", "*.delete", "*.manage", "*"}},
+		{input: []string{"products.*"}, groups: groups, expected: []string{"products.*", "*"}},
+		{input: []string{"products.*"}, expected: []string{"products.*", "*"}},
+		{input: []string{"*.get", "*"}, expected: []string{"*.get", "*"}},
+		{input: []string{"read"}, expected: nil},
+		{input: []string{"orders.get", "products.get"}, expected: []string{"products.get", "products.*", "*.get", "*"}},
+		{input: []string{"orders.get"}, expected: nil},
+		{input: []string{"products.post"}, expected: nil},
+		{input: []string{"products.read"}, groups: groups, expected: []string{"products.read", "products.manage", "products.*", "*.read", "*.manage", "*"}},
+		{input: []string{"orders.read"}, groups: groups, expected: nil},
+		{input: []string{"products.read"}, expected: nil},
+	}
+
+	for _, test := range tests {
+		if test.method == "" {
+			test.method = "get"
+		}
+		if output := permissionCandidates(test.input, "products", test.method, test.groups); !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("permissionCandidates(%v) returned %v, expected %v", test.input, output, test.expected)
+		}
+	}
+}
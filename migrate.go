@@ -0,0 +1,282 @@
+package confide_acl
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cangkir13/confide_acl/repository"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// migrationDownSuffix ends the file reverting a migration, "20240801_initial.down.sql" reverts "20240801_initial.sql".
+// the down statements are kept out of the up file so importing the up files manually never drops a table.
+const migrationDownSuffix = ".down.sql"
+
+// ErrNoDownMigration is returned by Migrator.Down when a migration has no down file.
+var ErrNoDownMigration = errors.New("migration has no down file")
+
+// migration is a versioned schema change read from the embedded migrations.
+type migration struct {
+	version string // file name without the .sql extension, "20240801_initial"
+	up      []string
+	down    []string
+}
+
+// Migrator applies the embedded migrations of a dialect and records them in the schema_migrations table.
+type Migrator struct {
+	db      *sql.DB
+	dialect repository.Dialect
+}
+
+// NewMigrator creates a Migrator applying the migrations of the dialect, MySQL when dialect is empty.
+func NewMigrator(db *sql.DB, dialect repository.Dialect) *Migrator {
+	if dialect == "" {
+		dialect = repository.DialectMySQL
+	}
+	return &Migrator{db: db, dialect: dialect}
+}
+
+// Migrate applies the MySQL migrations not applied yet, it can be called on every start.
+// use NewMigrator for the other dialects.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - db: The database connection.
+//
+// Returns:
+// - error: An error if one of the migrations fails, the migrations applied before it are kept.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return NewMigrator(db, repository.DialectMySQL).Up(ctx)
+}
+
+// Up applies every migration not recorded in schema_migrations in version order.
+// each migration runs in its own transaction, MySQL commits DDL statements implicitly
+// so a failing MySQL migration may be partially applied.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+//
+// Returns:
+// - error: An error if one of the migrations fails, otherwise nil.
+func (m *Migrator) Up(ctx context.Context) error {
+	migrations, err := m.migrations()
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	for _, migration := range migrations {
+		if done[migration.version] {
+			continue
+		}
+
+		err := m.run(ctx, migration.version, migration.up,
+			"INSERT INTO schema_migrations (version) VALUES (?)")
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", migration.version, err)
+		}
+	}
+
+	return nil
+}
+
+// Down reverts the last applied migrations, in reverse version order.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - steps: The number of migrations to revert, a value lower than 1 reverts every applied migration.
+//
+// Returns:
+// - error: ErrNoDownMigration if a migration can't be reverted, an error if one of the migrations fails, otherwise nil.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	migrations, err := m.migrations()
+	if err != nil {
+		return err
+	}
+
+	byVersion := make(map[string]migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.version] = migration
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		if steps > 0 && len(applied)-i > steps {
+			break
+		}
+
+		migration, ok := byVersion[applied[i]]
+		if !ok {
+			return fmt.Errorf("failed to revert migration %s: unknown version", applied[i])
+		}
+		if len(migration.down) == 0 {
+			return fmt.Errorf("failed to revert migration %s: %w", migration.version, ErrNoDownMigration)
+		}
+
+		err := m.run(ctx, migration.version, migration.down,
+			"DELETE FROM schema_migrations WHERE version = ?")
+		if err != nil {
+			return fmt.Errorf("failed to revert migration %s: %w", migration.version, err)
+		}
+	}
+
+	return nil
+}
+
+// Applied returns the versions recorded in schema_migrations in version order.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+//
+// Returns:
+// - []string: The applied versions, like "20240801_initial".
+// - error: An error if the query fails, otherwise nil.
+func (m *Migrator) Applied(ctx context.Context) ([]string, error) {
+	return m.appliedVersions(ctx)
+}
+
+// run executes the statements of a migration and records its version in the same transaction.
+func (m *Migrator) run(ctx context.Context, version string, statements []string, record string) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, m.dialect.Rebind(record), version); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// appliedVersions creates the schema_migrations table if needed and returns the applied versions.
+func (m *Migrator) appliedVersions(ctx context.Context) ([]string, error) {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version VARCHAR(255) NOT NULL PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return versions, nil
+}
+
+// migrations reads the embedded migrations of the dialect in version order,
+// the statements of "<version>.sql" apply the migration and the ones of "<version>.down.sql" revert it.
+func (m *Migrator) migrations() ([]migration, error) {
+	dir := "migrations"
+	if m.dialect != repository.DialectMySQL {
+		dir = path.Join(dir, string(m.dialect))
+	}
+
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s: %w", m.dialect, err)
+	}
+
+	byVersion := make(map[string]*migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		content, err := fs.ReadFile(migrationsFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		down := strings.HasSuffix(entry.Name(), migrationDownSuffix)
+		version := strings.TrimSuffix(strings.TrimSuffix(entry.Name(), migrationDownSuffix), ".sql")
+		if byVersion[version] == nil {
+			byVersion[version] = &migration{version: version}
+		}
+		if down {
+			byVersion[version].down = splitStatements(string(content))
+		} else {
+			byVersion[version].up = splitStatements(string(content))
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// splitStatements splits a SQL script on ";", comment lines are dropped.
+// the drivers don't all accept several statements in one Exec.
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
@@ -0,0 +1,135 @@
+package confide_acl_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cangkir13/confide_acl"
+	"github.com/cangkir13/confide_acl/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectAppliedVersions mocks the creation and the read of schema_migrations
+func expectAppliedVersions(mock sqlmock.Sqlmock, versions ...string) {
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, version := range versions {
+		rows.AddRow(version)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM schema_migrations ORDER BY version")).
+		WillReturnRows(rows)
+}
+
+func TestMigrate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// every migration is applied on an empty database
+	expectAppliedVersions(mock)
+	mock.ExpectBegin()
+	for _, table := range []string{"users", "roles", "permissions", "role_has_permissions", "user_has_roles", "user_has_permissions"} {
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS " + table + " (")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version) VALUES (?)")).
+		WithArgs("20240801_initial").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS role_has_roles (")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version) VALUES (?)")).
+		WithArgs("20240901_role_hierarchy").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS role_has_denied_permissions (")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS user_has_denied_permissions (")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version) VALUES (?)")).
+		WithArgs("20240915_deny_rules").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = confide_acl.Migrate(context.Background(), db)
+	require.NoError(t, err)
+
+	// nothing left to apply
+	expectAppliedVersions(mock, "20240801_initial", "20240901_role_hierarchy", "20240915_deny_rules")
+
+	err = confide_acl.Migrate(context.Background(), db)
+	require.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestMigratorDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator := confide_acl.NewMigrator(db, repository.DialectPostgres)
+
+	expectAppliedVersions(mock, "20240801_initial", "20240901_role_hierarchy", "20240915_deny_rules")
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS user_has_denied_permissions")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS role_has_denied_permissions")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).
+		WithArgs("20240915_deny_rules").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+
+	// a failing statement rolls the migration back
+	expectAppliedVersions(mock, "20240801_initial", "20240901_role_hierarchy")
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS role_has_roles")).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = migrator.Down(context.Background(), 1)
+	assert.ErrorIs(t, err, assert.AnError)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestMigrationFiles(t *testing.T) {
+	// the up files can be imported manually, they must not drop anything
+	files, err := filepath.Glob("migrations/*.sql")
+	require.NoError(t, err)
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		dialectFiles, err := filepath.Glob(filepath.Join("migrations", dialect, "*.sql"))
+		require.NoError(t, err)
+		files = append(files, dialectFiles...)
+	}
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		if strings.HasSuffix(file, ".down.sql") {
+			_, err := os.Stat(strings.TrimSuffix(file, ".down.sql") + ".sql")
+			assert.NoError(t, err, "%s reverts no migration", file)
+			continue
+		}
+		assert.NotContains(t, strings.ToUpper(string(content)), "DROP ", "%s", file)
+		_, err = os.Stat(strings.TrimSuffix(file, ".sql") + ".down.sql")
+		assert.NoError(t, err, "%s has no down file", file)
+	}
+}
@@ -59,4 +59,4 @@ CREATE TABLE IF NOT EXISTS user_has_permissions (
     PRIMARY KEY (user_id, permission_id),
     FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
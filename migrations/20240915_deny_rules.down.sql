@@ -0,0 +1,5 @@
+-- Migrations: 20240915_deny_rules.down.sql
+-- reverts 20240915_deny_rules.sql, applied by Migrator.Down
+
+DROP TABLE IF EXISTS user_has_denied_permissions;
+DROP TABLE IF EXISTS role_has_denied_permissions;
@@ -0,0 +1,9 @@
+-- Migrations: postgres/20240801_initial.down.sql
+-- reverts 20240801_initial.sql, applied by Migrator.Down
+
+-- the users table is kept, it may hold accounts created before this migration
+DROP TABLE IF EXISTS user_has_permissions;
+DROP TABLE IF EXISTS user_has_roles;
+DROP TABLE IF EXISTS role_has_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,4 @@
+-- Migrations: postgres/20240901_role_hierarchy.down.sql
+-- reverts 20240901_role_hierarchy.sql, applied by Migrator.Down
+
+DROP TABLE IF EXISTS role_has_roles;
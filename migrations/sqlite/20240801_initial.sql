@@ -0,0 +1,64 @@
+-- Migrations: sqlite/20240801_initial.sql
+-- foreign keys are disabled by default in SQLite, enable them on every connection
+-- with "PRAGMA foreign_keys = ON" or in the DSN
+
+-- default table for account users
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_on datetime DEFAULT NULL,
+  updated_on datetime DEFAULT NULL,
+  token_created_on datetime DEFAULT NULL,
+  email varchar(50) UNIQUE NOT NULL,
+  password varchar(60) DEFAULT NULL,
+  full_name varchar(50) NOT NULL,
+  department_id INTEGER NOT NULL,
+  title_id INTEGER NOT NULL,
+  role_name varchar(50) DEFAULT NULL,
+  salesperson_code varchar(50) DEFAULT NULL,
+  salesperson_branch_code varchar(50) DEFAULT NULL,
+  token varchar(200) DEFAULT NULL,
+  is_enabled INTEGER NOT NULL DEFAULT 1
+);
+
+-- Create roles table
+CREATE TABLE IF NOT EXISTS roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create permissions table
+CREATE TABLE IF NOT EXISTS permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create role_has_permissions table
+CREATE TABLE IF NOT EXISTS role_has_permissions (
+    role_id INTEGER NOT NULL,
+    permission_id INTEGER NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+-- Create user_has_roles table
+CREATE TABLE IF NOT EXISTS user_has_roles (
+    user_id INTEGER NOT NULL,
+    role_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create user_has_permissions table
+CREATE TABLE IF NOT EXISTS user_has_permissions (
+    user_id INTEGER NOT NULL,
+    permission_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, permission_id),
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- Migrations: sqlite/20240901_role_hierarchy.sql
+
+-- Create role_has_roles table, role_id inherits the permissions of parent_role_id
+CREATE TABLE IF NOT EXISTS role_has_roles (
+    role_id INTEGER NOT NULL,
+    parent_role_id INTEGER NOT NULL,
+    PRIMARY KEY (role_id, parent_role_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
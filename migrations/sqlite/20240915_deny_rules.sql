@@ -0,0 +1,19 @@
+-- Migrations: sqlite/20240915_deny_rules.sql
+
+-- Create role_has_denied_permissions table, a denied permission overrides every grant
+CREATE TABLE IF NOT EXISTS role_has_denied_permissions (
+    role_id INTEGER NOT NULL,
+    permission_id INTEGER NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+-- Create user_has_denied_permissions table
+CREATE TABLE IF NOT EXISTS user_has_denied_permissions (
+    user_id INTEGER NOT NULL,
+    permission_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, permission_id),
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,128 @@
+package confide_acl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultPathSeparator joins the segments of a normalized path, "/api/v1/products/17/reviews" gives "products.reviews"
+const defaultPathSeparator = "."
+
+// versionSegment is an API version segment of a path, "v1", "v2", ...
+var versionSegment = regexp.MustCompile(`(?i)^v[0-9]+$`)
+
+// uuidSegment is a UUID segment of a path
+var uuidSegment = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// PathNormalizer converts the modules given as URL paths to the modules of the permission names, so
+// PolicyACL(ctx, userID, policy, "/api/v1/products/17", "GET") checks "products.get".
+// the modules not starting with "/" are left as is.
+//
+// Example:
+//
+//	confide_acl.ConfigACL{
+//		Database: db,
+//		PathNormalizer: &confide_acl.PathNormalizer{
+//			Prefixes:     []string{"/api"},
+//			StripVersion: true,
+//			Modules:      map[string]string{"products.reviews": "reviews"},
+//		},
+//	}
+type PathNormalizer struct {
+	// Prefixes are removed from the start of the path, the first matching one wins, like "/api"
+	Prefixes []string
+	// StripVersion removes the version segment following the prefix, like "v1"
+	StripVersion bool
+	// Placeholder replaces the path parameters, like ":id", the parameters are removed when it is empty.
+	// the parameters are the numeric and UUID segments, the "{name}" segments of route patterns and the
+	// segments matching one of Parameters
+	Placeholder string
+	// Parameters are patterns of additional path parameters, like regexp.MustCompile(`^[a-z0-9]{24}$`)
+	Parameters []*regexp.Regexp
+	// Separator joins the remaining segments, "." by default
+	Separator string
+	// Modules maps the normalized path to a module name, like "products.reviews" to "reviews"
+	Modules map[string]string
+}
+
+// Normalize returns the module of a path, see PathNormalizer.
+//
+// Parameters:
+// - path: The path, like "/api/v1/products/17". a query string is ignored.
+//
+// Returns:
+// - string: The module, like "products". a module not starting with "/" is returned as is.
+func (n *PathNormalizer) Normalize(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return path
+	}
+
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	for _, prefix := range n.Prefixes {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			path = path[len(prefix):]
+			break
+		}
+	}
+
+	var segments []string
+	for i, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case segment == "":
+			continue
+		case i == 0 && n.StripVersion && versionSegment.MatchString(segment):
+			continue
+		case n.isParameter(segment):
+			if n.Placeholder != "" {
+				segments = append(segments, n.Placeholder)
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	separator := n.Separator
+	if separator == "" {
+		separator = defaultPathSeparator
+	}
+
+	module := strings.Join(segments, separator)
+	if mapped, ok := n.Modules[module]; ok {
+		return mapped
+	}
+	return module
+}
+
+// isParameter reports whether a segment of a path is a path parameter.
+func (n *PathNormalizer) isParameter(segment string) bool {
+	if isDigits(segment) || uuidSegment.MatchString(segment) {
+		return true
+	}
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return true
+	}
+
+	for _, parameter := range n.Parameters {
+		if parameter.MatchString(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDigits reports whether s is a non empty string of decimal digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package confide_acl
+
+import (
+	"regexp"
+	"testing"
+)
+
+// Unit test for PathNormalizer.Normalize
+func TestPathNormalizer(t *testing.T) {
+	normalizer := &PathNormalizer{
+		Prefixes:     []string{"/api", "/internal/"},
+		StripVersion: true,
+		Modules:      map[string]string{"products.reviews": "reviews"},
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/api/v1/products", expected: "products"},
+		{path: "/api/v1/products/17", expected: "products"},
+		{path: "/api/v2/products/17/", expected: "products"},
+		{path: "/api/v1/products/{id}", expected: "products"},
+		{path: "/api/v1/products/17/variants/3", expected: "products.variants"},
+		{path: "/api/v1/products/17/reviews", expected: "reviews"},
+		{path: "/api/v1/orders/6f1c2a3e-58b4-4c1e-9d2f-2b7d1a0c9e41?expand=items", expected: "orders"},
+		{path: "/internal/v1/stats", expected: "stats"},
+		{path: "/apis/v1/stats", expected: "apis.v1.stats"},
+		{path: "/products", expected: "products"},
+		{path: "products", expected: "products"},
+	}
+
+	for _, test := range tests {
+		if module := normalizer.Normalize(test.path); module != test.expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", test.path, module, test.expected)
+		}
+	}
+}
+
+// Unit test for the placeholder, separator and parameter patterns of PathNormalizer
+func TestPathNormalizerPlaceholder(t *testing.T) {
+	normalizer := &PathNormalizer{
+		Placeholder: ":id",
+		Separator:   "/",
+		Parameters:  []*regexp.Regexp{regexp.MustCompile(`^[0-9a-f]{24}$`)},
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/v1/products/17", expected: "v1/products/:id"},
+		{path: "/products/5f8d0d55b54764421b7156c9/reviews", expected: "products/:id/reviews"},
+		{path: "/products/{id}/reviews/{review}", expected: "products/:id/reviews/:id"},
+	}
+
+	for _, test := range tests {
+		if module := normalizer.Normalize(test.path); module != test.expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", test.path, module, test.expected)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package confide_acl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// policyNode is a node of the AST produced by parsePolicy.
+type policyNode interface {
+	String() string
+}
+
+// policyTerm is a leaf of the policy, a single "role:<roles>" or "permission:<permissions>" clause.
+type policyTerm struct {
+	raw            string
+	rolePermission RolePermission
+}
+
+// policyNot negates the result of its operand.
+type policyNot struct {
+	operand policyNode
+}
+
+// policyAnd is satisfied when every operand is satisfied.
+type policyAnd struct {
+	operands []policyNode
+}
+
+// policyOr is satisfied when at least one operand is satisfied.
+type policyOr struct {
+	operands []policyNode
+}
+
+func (t policyTerm) String() string { return t.raw }
+
+func (n policyNot) String() string { return "NOT " + n.operand.String() }
+
+func (a policyAnd) String() string { return joinPolicyNodes(a.operands, " AND ") }
+
+func (o policyOr) String() string { return joinPolicyNodes(o.operands, " OR ") }
+
+// Helper function to print operands of a binary operator wrapped in parentheses
+func joinPolicyNodes(nodes []policyNode, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+type policyTokenKind int
+
+const (
+	tokenEOF policyTokenKind = iota
+	tokenTerm
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type policyToken struct {
+	kind  policyTokenKind
+	value string
+	pos   int
+}
+
+// parsePolicy parses a policy expression and returns its AST.
+//
+// The grammar, from the lowest to the highest precedence:
+//
+//	expr   = and { ("OR" | "||" | "|") and }
+//	and    = unary { ("AND" | "&&" | "&") unary }
+//	unary  = ("NOT" | "!") unary | "(" expr ")" | term
+//	term   = ("role" | "permission") ":" name { "," name }
+//
+// Keywords are case insensitive and "NOT NOT x" is parsed as x. The policy is evaluated as a boolean
+// expression, a negated clause being the membership of the role or the permission: "NOT role:suspended"
+// allows every user not holding suspended, and "role:editor OR NOT role:suspended" allows the editors
+// and every user not holding suspended.
+//
+// The flat syntax "role:admin,user|permission:product.create" is a valid expression, "|" being the OR
+// operator. Without parentheses, "!", "&", "||" and keywords the input is parsed as the flat syntax, so
+// the names may contain spaces like "role:Super Admin". An input without keywords that is not a valid
+// expression is parsed as the flat syntax as well, "role:R&D" holds the "R&D" role.
+//
+// Parameters:
+// - input: the policy expression to be parsed.
+//
+// Returns:
+// - policyNode: the root of the AST.
+// - error: an error wrapping ErrInvalidParseFormat or ErrUnknownKey if the expression is invalid.
+func parsePolicy(input string) (policyNode, error) {
+	// names may contain spaces in the flat syntax, "role:Super Admin" is not split into terms
+	if isLegacyPolicy(input) {
+		return parseLegacyPolicy(input)
+	}
+
+	node, err := parseExpression(input)
+	if err != nil && !hasPolicyKeyword(input) {
+		// "(", ")", "!", "&" and "||" may belong to the names of the flat syntax, "role:R&D"
+		if legacy, legacyErr := parseLegacyPolicy(input); legacyErr == nil {
+			return legacy, nil
+		}
+	}
+	return node, err
+}
+
+// parseExpression parses the input with the operators of the expression grammar.
+func parseExpression(input string) (policyNode, error) {
+	p := &policyParser{tokens: tokenizePolicy(input)}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalidParseFormat, tok.value, tok.pos)
+	}
+	return node, nil
+}
+
+// isLegacyPolicy reports whether the input is written in the flat syntax "role:admin,user|permission:product.create",
+// without parentheses, "!", "&", "||" or a AND, OR or NOT keyword.
+func isLegacyPolicy(input string) bool {
+	if strings.ContainsAny(input, "()!&") || strings.Contains(input, "||") {
+		return false
+	}
+	return !hasPolicyKeyword(input)
+}
+
+// hasPolicyKeyword reports whether a word of the input is the AND, OR or NOT keyword.
+func hasPolicyKeyword(input string) bool {
+	for _, word := range strings.Fields(input) {
+		switch strings.ToUpper(word) {
+		case "AND", "OR", "NOT":
+			return true
+		}
+	}
+	return false
+}
+
+// parseLegacyPolicy parses the flat syntax, every "|" separated part is a term and the spaces inside a part
+// belong to the names, "role:Super Admin|permission:product.create" holds the "Super Admin" role.
+func parseLegacyPolicy(input string) (policyNode, error) {
+	var operands []policyNode
+	pos := 0
+	for _, part := range strings.Split(input, "|") {
+		term := strings.TrimSpace(part)
+		node, err := parsePolicyTerm(policyToken{kind: tokenTerm, value: term, pos: pos + strings.Index(part, term)})
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, node)
+		pos += len(part) + 1
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return policyOr{operands: operands}, nil
+}
+
+// tokenizePolicy splits the policy expression into tokens, terms are validated later by the parser.
+func tokenizePolicy(input string) []policyToken {
+	var tokens []policyToken
+
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, policyToken{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, policyToken{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case c == '!':
+			tokens = append(tokens, policyToken{kind: tokenNot, value: "!", pos: i})
+			i++
+		case c == '|' || c == '&':
+			kind := tokenOr
+			if c == '&' {
+				kind = tokenAnd
+			}
+			start := i
+			i++
+			// "||" and "&&" are accepted as well as the single character form
+			if i < len(input) && input[i] == c {
+				i++
+			}
+			tokens = append(tokens, policyToken{kind: kind, value: input[start:i], pos: start})
+		default:
+			start := i
+			for i < len(input) && !strings.ContainsRune(" \t\n\r()!|&", rune(input[i])) {
+				i++
+			}
+			word := input[start:i]
+
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, policyToken{kind: tokenAnd, value: word, pos: start})
+			case "OR":
+				tokens = append(tokens, policyToken{kind: tokenOr, value: word, pos: start})
+			case "NOT":
+				tokens = append(tokens, policyToken{kind: tokenNot, value: word, pos: start})
+			default:
+				tokens = append(tokens, policyToken{kind: tokenTerm, value: word, pos: start})
+			}
+		}
+	}
+
+	return append(tokens, policyToken{kind: tokenEOF, pos: len(input)})
+}
+
+// policyParser is a recursive descent parser over the tokens of a policy expression.
+type policyParser struct {
+	tokens []policyToken
+	pos    int
+}
+
+func (p *policyParser) peek() policyToken {
+	return p.tokens[p.pos]
+}
+
+func (p *policyParser) next() policyToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *policyParser) parseOr() (policyNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []policyNode{node}
+	for p.peek().kind == tokenOr {
+		p.next()
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, node)
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return policyOr{operands: operands}, nil
+}
+
+func (p *policyParser) parseAnd() (policyNode, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []policyNode{node}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, node)
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return policyAnd{operands: operands}, nil
+}
+
+func (p *policyParser) parseUnary() (policyNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNot:
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		// a double negation is its operand
+		if not, ok := operand.(policyNot); ok {
+			return not.operand, nil
+		}
+		return policyNot{operand: operand}, nil
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("%w: missing \")\" at position %d", ErrInvalidParseFormat, closing.pos)
+		}
+		return node, nil
+	case tokenTerm:
+		return parsePolicyTerm(tok)
+	case tokenEOF:
+		return nil, fmt.Errorf("%w: unexpected end of policy at position %d", ErrInvalidParseFormat, tok.pos)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalidParseFormat, tok.value, tok.pos)
+	}
+}
+
+// parsePolicyTerm converts a term token into a leaf of the AST.
+func parsePolicyTerm(tok policyToken) (policyNode, error) {
+	rp, err := parseRolePermission(tok.value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q at position %d", err, tok.value, tok.pos)
+	}
+
+	for _, names := range [][]string{rp.Roles, rp.Permissions} {
+		for _, name := range names {
+			if name == "" {
+				return nil, fmt.Errorf("%w: empty name in %q at position %d", ErrInvalidParseFormat, tok.value, tok.pos)
+			}
+		}
+	}
+
+	return policyTerm{raw: tok.value, rolePermission: rp}, nil
+}
@@ -0,0 +1,54 @@
+package confide_acl
+
+import (
+	"errors"
+	"testing"
+)
+
+// Unit test for parsePolicy
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+		expectedError  error
+	}{
+		{input: "role:admin", expectedOutput: "role:admin"},
+		{input: "role:admin,user|permission:read", expectedOutput: "(role:admin,user OR permission:read)"},
+		{input: "role:editor AND permission:articles.publish", expectedOutput: "(role:editor AND permission:articles.publish)"},
+		{input: "NOT role:suspended", expectedOutput: "NOT role:suspended"},
+		{input: "NOT role:a OR !role:b", expectedOutput: "(NOT role:a OR NOT role:b)"},
+		{input: "NOT NOT role:editor", expectedOutput: "role:editor"},
+		{input: "NOT (NOT role:editor AND role:suspended)", expectedOutput: "NOT (NOT role:editor AND role:suspended)"},
+		{input: "!role:suspended && role:editor", expectedOutput: "(NOT role:suspended AND role:editor)"},
+		{input: "role:a or role:b and role:c", expectedOutput: "(role:a OR (role:b AND role:c))"},
+		{input: "(role:a OR role:b) AND NOT (role:c || permission:d)", expectedOutput: "((role:a OR role:b) AND NOT (role:c OR permission:d))"},
+		{input: "role:Super Admin", expectedOutput: "role:Super Admin"},
+		{input: "role:Super Admin,editor|permission:product.create", expectedOutput: "(role:Super Admin,editor OR permission:product.create)"},
+		{input: "role:Super Admin AND permission:product.create", expectedError: ErrInvalidParseFormat},
+		{input: "role:R&D", expectedOutput: "role:R&D"},
+		{input: "role:R&D,editor|permission:(beta).get", expectedOutput: "(role:R&D,editor OR permission:(beta).get)"},
+		{input: "role:R&D OR role:editor", expectedError: ErrInvalidParseFormat},
+		{input: "role:a&role:b", expectedOutput: "(role:a AND role:b)"},
+		{input: "NOT (NOT role:a AND NOT role:b)", expectedOutput: "NOT (NOT role:a AND NOT role:b)"},
+		{input: "", expectedError: ErrInvalidParseFormat},
+		{input: "invalid_format", expectedError: ErrInvalidParseFormat},
+		{input: "unknownkey:value", expectedError: ErrUnknownKey},
+		{input: "role:", expectedError: ErrInvalidParseFormat},
+		{input: "role:a,,b", expectedError: ErrInvalidParseFormat},
+		{input: "role:a AND", expectedError: ErrInvalidParseFormat},
+		{input: "(role:a OR role:b", expectedError: ErrInvalidParseFormat},
+		{input: "role:a role:b", expectedError: ErrInvalidParseFormat},
+		{input: "role:a)", expectedOutput: "role:a)"},
+	}
+
+	for _, test := range tests {
+		output, err := parsePolicy(test.input)
+		if !errors.Is(err, test.expectedError) {
+			t.Errorf("parsePolicy(%q) returned error: %v, expected error: %v", test.input, err, test.expectedError)
+			continue
+		}
+		if err == nil && output.String() != test.expectedOutput {
+			t.Errorf("parsePolicy(%q) returned %s, expected %s", test.input, output, test.expectedOutput)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL flavour of the database behind SQL.
+// The queries are written with MySQL "?" placeholders and rewritten for the other dialects.
+type Dialect string
+
+const (
+	// DialectMySQL is the default dialect, an empty Dialect is MySQL.
+	DialectMySQL Dialect = "mysql"
+	// DialectPostgres uses $n placeholders and SQLSTATE codes, see migrations/postgres for the schema.
+	DialectPostgres Dialect = "postgres"
+	// DialectSQLite uses "?" placeholders like MySQL, see migrations/sqlite for the schema.
+	// foreign keys are enabled on the connection before the deletes relying on ON DELETE CASCADE,
+	// enable them in the DSN as well ("_foreign_keys=on" or "_pragma=foreign_keys(1)") for the other statements.
+	DialectSQLite Dialect = "sqlite"
+)
+
+// errorSQLiteUniqueConstraint is the message of a duplicate key in SQLite, for both the mattn and modernc drivers
+const errorSQLiteUniqueConstraint = "UNIQUE constraint failed"
+
+// ErrUnsupportedDialect is returned by SQL.Validate for an unknown dialect.
+var ErrUnsupportedDialect = errors.New("unsupported dialect")
+
+// sqlStatePostgresUniqueViolation is the SQLSTATE of a duplicate key in PostgreSQL
+const sqlStatePostgresUniqueViolation = "23505"
+
+// errorNumberMySQLDuplicateEntry is the error number of a duplicate key in MySQL and MariaDB
+const errorNumberMySQLDuplicateEntry = "1062"
+
+// mysqlErrorNumber matches the error number in the message of the MySQL driver errors,
+// "Error 1062 (23000): Duplicate entry 'admin' for key 'name'" for *mysql.MySQLError (github.com/go-sql-driver/mysql).
+var mysqlErrorNumber = regexp.MustCompile(`Error (\d+)\b`)
+
+// sqlStateError is implemented by the errors of the PostgreSQL drivers,
+// *pq.Error (github.com/lib/pq) and *pgconn.PgError (github.com/jackc/pgx).
+type sqlStateError interface {
+	SQLState() string
+}
+
+// Option configures a SQL repository built by NewSQL.
+type Option func(*SQL)
+
+// WithDialect sets the dialect of the database, DialectMySQL by default.
+// an empty dialect keeps the default.
+func WithDialect(dialect Dialect) Option {
+	return func(s *SQL) {
+		if dialect != "" {
+			s.dialect = dialect
+		}
+	}
+}
+
+// validate checks the dialect is one of DialectMySQL, DialectPostgres and DialectSQLite.
+func (d Dialect) validate() error {
+	switch d {
+	case DialectMySQL, DialectPostgres, DialectSQLite:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedDialect, string(d))
+}
+
+// quoteIdentifier quotes a table or column name, each part of a qualified "schema.table" name is quoted.
+// backticks in MySQL, double quotes in PostgreSQL and SQLite. a quote inside the name is doubled.
+func (d Dialect) quoteIdentifier(name string) string {
+	quote := `"`
+	if d == DialectMySQL {
+		quote = "`"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// Rebind rewrites the "?" placeholders of a query for the dialect.
+func (d Dialect) Rebind(query string) string {
+	if d != DialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+
+	return b.String()
+}
+
+// isUniqueViolation reports whether err is a duplicate key error of the dialect.
+func (d Dialect) isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch d {
+	case DialectPostgres:
+		var stateErr sqlStateError
+		if errors.As(err, &stateErr) {
+			return stateErr.SQLState() == sqlStatePostgresUniqueViolation
+		}
+		// drivers without SQLState, the SQLSTATE is part of the message
+		return strings.Contains(err.Error(), sqlStatePostgresUniqueViolation) ||
+			strings.Contains(err.Error(), "duplicate key value violates unique constraint")
+	case DialectSQLite:
+		return strings.Contains(err.Error(), errorSQLiteUniqueConstraint)
+	default:
+		// the number is read from the message, the library does not depend on the MySQL driver
+		if match := mysqlErrorNumber.FindStringSubmatch(err.Error()); match != nil {
+			return match[1] == errorNumberMySQLDuplicateEntry
+		}
+		// drivers without error number, the message names the duplicate entry
+		return strings.Contains(err.Error(), ErrorDuplicateEntry)
+	}
+}
+
+// insertIgnore returns an INSERT statement skipping the rows already stored.
+func (d Dialect) insertIgnore(table string, columns ...string) string {
+	placeholders := make([]string, len(columns))
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+
+	values := "(" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+
+	switch d {
+	case DialectPostgres:
+		return "INSERT INTO " + table + " " + values + " ON CONFLICT DO NOTHING"
+	case DialectSQLite:
+		return "INSERT OR IGNORE INTO " + table + " " + values
+	default:
+		return "INSERT IGNORE INTO " + table + " " + values
+	}
+}
+
+// offsetWithoutLimit returns the LIMIT clause required before an OFFSET without limit, if any.
+func (d Dialect) offsetWithoutLimit() string {
+	switch d {
+	case DialectPostgres:
+		return ""
+	case DialectSQLite:
+		// a negative LIMIT has no upper bound in SQLite
+		return " LIMIT -1"
+	default:
+		// OFFSET requires a LIMIT in MySQL, use the largest BIGINT UNSIGNED value
+		return " LIMIT 18446744073709551615"
+	}
+}
+
+// execWithForeignKeys executes a statement relying on the foreign keys, like the ON DELETE CASCADE of a delete.
+// SQLite enforces foreign keys per connection and only when enabled, so the statement runs on a
+// single connection of the pool after "PRAGMA foreign_keys = ON".
+func (s *SQL) execWithForeignKeys(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if s.dialect != DialectSQLite {
+		return s.db.ExecContext(ctx, s.render(query), args...)
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
+		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	return conn.ExecContext(ctx, s.render(query), args...)
+}
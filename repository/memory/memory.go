@@ -0,0 +1,569 @@
+// Package memory is an in-memory implementation of repository.RepositoryService.
+// It keeps the semantics of repository.SQL (sentinel errors, all-or-nothing batches,
+// ON DELETE CASCADE) and is safe for concurrent use, it is meant for tests and small deployments
+// where the roles and permissions don't need to survive a restart.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cangkir13/confide_acl/repository"
+)
+
+// Store keeps roles, permissions and their assignments in memory.
+// user IDs are not checked against an account table, an account name can be set with SetAccountName.
+type Store struct {
+	mu sync.RWMutex
+
+	lastRoleID       uint
+	lastPermissionID uint
+
+	roles         map[uint]string
+	roleIDs       map[string]uint
+	permissions   map[uint]string
+	permissionIDs map[string]uint
+	accounts      map[uint]string
+
+	rolePermissions       relation // role_has_permissions
+	userRoles             relation // user_has_roles
+	userPermissions       relation // user_has_permissions
+	roleParents           relation // role_has_roles
+	roleDeniedPermissions relation // role_has_denied_permissions
+	userDeniedPermissions relation // user_has_denied_permissions
+}
+
+// Store must keep implementing repository.RepositoryService
+var _ repository.RepositoryService = (*Store)(nil)
+
+// New creates an empty in-memory store.
+func New() *Store {
+	return &Store{
+		roles:                 make(map[uint]string),
+		roleIDs:               make(map[string]uint),
+		permissions:           make(map[uint]string),
+		permissionIDs:         make(map[string]uint),
+		accounts:              make(map[uint]string),
+		rolePermissions:       make(relation),
+		userRoles:             make(relation),
+		userPermissions:       make(relation),
+		roleParents:           make(relation),
+		roleDeniedPermissions: make(relation),
+		userDeniedPermissions: make(relation),
+	}
+}
+
+// SetAccountName sets the full name returned for the user in AccountRole.
+func (s *Store) SetAccountName(userID uint, fullName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts[userID] = fullName
+}
+
+// CreateRole adds a role, ErrDuplicateRole is returned if the name is taken.
+func (s *Store) CreateRole(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.roleIDs[name]; ok {
+		return repository.ErrDuplicateRole
+	}
+
+	s.lastRoleID++
+	s.roles[s.lastRoleID] = name
+	s.roleIDs[name] = s.lastRoleID
+	return nil
+}
+
+// CreatePermission adds a permission, ErrDuplicatePermission is returned if the name is taken.
+func (s *Store) CreatePermission(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.permissionIDs[name]; ok {
+		return repository.ErrDuplicatePermission
+	}
+
+	s.lastPermissionID++
+	s.permissions[s.lastPermissionID] = name
+	s.permissionIDs[name] = s.lastPermissionID
+	return nil
+}
+
+// DeleteRole removes a role and every assignment of the role, ErrRoleNotFound is returned if it does not exist.
+func (s *Store) DeleteRole(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roleID, ok := s.roleIDs[name]
+	if !ok {
+		return repository.ErrRoleNotFound
+	}
+
+	delete(s.roles, roleID)
+	delete(s.roleIDs, name)
+	s.rolePermissions.removeLeft(roleID)
+	s.userRoles.removeRight(roleID)
+	s.roleParents.removeLeft(roleID)
+	s.roleParents.removeRight(roleID)
+	s.roleDeniedPermissions.removeLeft(roleID)
+	return nil
+}
+
+// DeletePermission removes a permission and every assignment of the permission,
+// ErrPermissionNotFound is returned if it does not exist.
+func (s *Store) DeletePermission(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	permissionID, ok := s.permissionIDs[name]
+	if !ok {
+		return repository.ErrPermissionNotFound
+	}
+
+	delete(s.permissions, permissionID)
+	delete(s.permissionIDs, name)
+	s.rolePermissions.removeRight(permissionID)
+	s.userPermissions.removeRight(permissionID)
+	s.roleDeniedPermissions.removeRight(permissionID)
+	s.userDeniedPermissions.removeRight(permissionID)
+	return nil
+}
+
+// RenameRole changes the name of a role, ErrRoleNotFound is returned if it does not exist
+// and ErrDuplicateRole if newName is taken by another role.
+func (s *Store) RenameRole(ctx context.Context, name, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roleID, ok := s.roleIDs[name]
+	if !ok {
+		return repository.ErrRoleNotFound
+	}
+	if other, ok := s.roleIDs[newName]; ok && other != roleID {
+		return repository.ErrDuplicateRole
+	}
+
+	delete(s.roleIDs, name)
+	s.roles[roleID] = newName
+	s.roleIDs[newName] = roleID
+	return nil
+}
+
+// RenamePermission changes the name of a permission, ErrPermissionNotFound is returned if it does not exist
+// and ErrDuplicatePermission if newName is taken by another permission.
+func (s *Store) RenamePermission(ctx context.Context, name, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	permissionID, ok := s.permissionIDs[name]
+	if !ok {
+		return repository.ErrPermissionNotFound
+	}
+	if other, ok := s.permissionIDs[newName]; ok && other != permissionID {
+		return repository.ErrDuplicatePermission
+	}
+
+	delete(s.permissionIDs, name)
+	s.permissions[permissionID] = newName
+	s.permissionIDs[newName] = permissionID
+	return nil
+}
+
+// GetAccountRolesByID returns every role of the user ordered by role ID.
+func (s *Store) GetAccountRolesByID(ctx context.Context, userID uint) ([]repository.AccountRole, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var accountRoles []repository.AccountRole
+	for _, roleID := range s.userRoles.rights(userID) {
+		accountRoles = append(accountRoles, repository.AccountRole{FullName: s.accounts[userID], RoleName: s.roles[roleID]})
+	}
+	return accountRoles, nil
+}
+
+// GetAccountHasPermission returns the permissions of ps assigned directly to the user.
+func (s *Store) GetAccountHasPermission(ctx context.Context, userid uint, ps []string) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make(map[string]bool, len(ps))
+	for _, name := range ps {
+		names[name] = true
+	}
+
+	var permissions []repository.Permission
+	for _, permissionID := range s.userPermissions.rights(userid) {
+		if names[s.permissions[permissionID]] {
+			permissions = append(permissions, repository.Permission{ID: permissionID, Name: s.permissions[permissionID]})
+		}
+	}
+	return permissions, nil
+}
+
+// GetPermissionIDByName returns the IDs of the named permissions, a *NotFoundError listing the missing names if one does not exist.
+func (s *Store) GetPermissionIDByName(ctx context.Context, permissions []string) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if missing := missingNames(s.permissionIDs, permissions); len(missing) > 0 || len(permissions) == 0 {
+		return nil, &repository.NotFoundError{Err: repository.ErrPermissionNotFound, Names: missing}
+	}
+	return lookup(s.permissionIDs, permissions), nil
+}
+
+// GetRoleIDByName returns the IDs of the named roles, a *NotFoundError listing the missing names if one does not exist.
+func (s *Store) GetRoleIDByName(ctx context.Context, names []string) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if missing := missingNames(s.roleIDs, names); len(missing) > 0 || len(names) == 0 {
+		return nil, &repository.NotFoundError{Err: repository.ErrRoleNotFound, Names: missing}
+	}
+	return lookup(s.roleIDs, names), nil
+}
+
+// GetRolesByName returns the named roles ordered by ID, unknown names are ignored.
+func (s *Store) GetRolesByName(ctx context.Context, names []string) ([]repository.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var roles []repository.Role
+	for _, roleID := range lookup(s.roleIDs, names) {
+		roles = append(roles, repository.Role{ID: roleID, Name: s.roles[roleID]})
+	}
+	return roles, nil
+}
+
+// GivePermissionToRole assigns the permissions to the role, ErrDuplicateRolePermission is returned
+// if the role already has one of them. nothing is assigned when an error is returned.
+func (s *Store) GivePermissionToRole(ctx context.Context, roleID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRole(roleID); err != nil {
+		return fmt.Errorf("failed to assign permissions to role %d: %w", roleID, err)
+	}
+	if err := s.checkPermissions(permissions); err != nil {
+		return fmt.Errorf("failed to assign permissions to role %d: %w", roleID, err)
+	}
+	if _, ok := s.rolePermissions.firstDuplicate(roleID, permissions); ok {
+		return repository.ErrDuplicateRolePermission
+	}
+
+	s.rolePermissions.add(roleID, permissions...)
+	return nil
+}
+
+// GiveRoleToUser assigns the role to the user, ErrDuplicateUserRole is returned if the user already holds it.
+func (s *Store) GiveRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRole(roleID); err != nil {
+		return fmt.Errorf("failed to assign role %d to user %d: %w", roleID, userID, err)
+	}
+	if s.userRoles.has(userID, roleID) {
+		return repository.ErrDuplicateUserRole
+	}
+
+	s.userRoles.add(userID, roleID)
+	return nil
+}
+
+// RevokePermissionFromRole removes the permissions from the role, ErrPermissionNotAssigned is returned
+// if one of them is not assigned. nothing is revoked when an error is returned.
+func (s *Store) RevokePermissionFromRole(ctx context.Context, roleID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.rolePermissions.hasAll(roleID, permissions) {
+		return repository.ErrPermissionNotAssigned
+	}
+
+	s.rolePermissions.remove(roleID, permissions...)
+	return nil
+}
+
+// RevokeRoleFromUser removes the role from the user, ErrUserRoleNotAssigned is returned if the user does not hold it.
+func (s *Store) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userRoles.has(userID, roleID) {
+		return repository.ErrUserRoleNotAssigned
+	}
+
+	s.userRoles.remove(userID, roleID)
+	return nil
+}
+
+// GivePermissionToUser assigns the permissions directly to the user, ErrDuplicateUserPermission is returned
+// if the user already has one of them. nothing is assigned when an error is returned.
+func (s *Store) GivePermissionToUser(ctx context.Context, userID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkPermissions(permissions); err != nil {
+		return fmt.Errorf("failed to assign permissions to user %d: %w", userID, err)
+	}
+	if _, ok := s.userPermissions.firstDuplicate(userID, permissions); ok {
+		return repository.ErrDuplicateUserPermission
+	}
+
+	s.userPermissions.add(userID, permissions...)
+	return nil
+}
+
+// RevokePermissionFromUser removes the permissions from the user, ErrUserPermissionNotAssigned is returned
+// if one of them is not assigned. nothing is revoked when an error is returned.
+func (s *Store) RevokePermissionFromUser(ctx context.Context, userID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userPermissions.hasAll(userID, permissions) {
+		return repository.ErrUserPermissionNotAssigned
+	}
+
+	s.userPermissions.remove(userID, permissions...)
+	return nil
+}
+
+// ListRoles returns the roles ordered by ID.
+func (s *Store) ListRoles(ctx context.Context, page repository.Pagination) ([]repository.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.Paginate(s.roleList(sortedKeys(s.roles)), page), nil
+}
+
+// ListPermissions returns the permissions ordered by ID.
+func (s *Store) ListPermissions(ctx context.Context, page repository.Pagination) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.Paginate(s.permissionList(sortedKeys(s.permissions)), page), nil
+}
+
+// GetRolePermissions returns the permissions assigned to the role ordered by ID.
+func (s *Store) GetRolePermissions(ctx context.Context, roleID uint, page repository.Pagination) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.Paginate(s.permissionList(s.rolePermissions.rights(roleID)), page), nil
+}
+
+// GetUserRoles returns the roles held by the user ordered by ID.
+func (s *Store) GetUserRoles(ctx context.Context, userID uint, page repository.Pagination) ([]repository.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.Paginate(s.roleList(s.userRoles.rights(userID)), page), nil
+}
+
+// GetUserDirectPermissions returns the permissions assigned directly to the user ordered by ID.
+func (s *Store) GetUserDirectPermissions(ctx context.Context, userID uint, page repository.Pagination) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.Paginate(s.permissionList(s.userPermissions.rights(userID)), page), nil
+}
+
+// ListUsersWithRole returns the IDs of the users holding the role ordered by ID.
+func (s *Store) ListUsersWithRole(ctx context.Context, roleID uint, page repository.Pagination) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return repository.Paginate(s.userRoles.lefts(roleID), page), nil
+}
+
+// GetPermissionsByRoleIDs returns the distinct permissions assigned to any of the roles ordered by ID.
+func (s *Store) GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uint) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.permissionList(s.rolePermissions.union(roleIDs)), nil
+}
+
+// GetRoleHierarchy returns every parent relation ordered by role and parent ID.
+func (s *Store) GetRoleHierarchy(ctx context.Context) ([]repository.RoleHasRole, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hierarchy []repository.RoleHasRole
+	for _, roleID := range sortedKeys(s.roleParents) {
+		for _, parentID := range s.roleParents.rights(roleID) {
+			hierarchy = append(hierarchy, repository.RoleHasRole{RoleID: roleID, ParentRoleID: parentID})
+		}
+	}
+	return hierarchy, nil
+}
+
+// SetRoleParents replaces the parents of the role, cycles are not checked here.
+func (s *Store) SetRoleParents(ctx context.Context, roleID uint, parentIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRole(roleID); err != nil {
+		return fmt.Errorf("failed to set parents of role %d: %w", roleID, err)
+	}
+	for _, parentID := range parentIDs {
+		if err := s.checkRole(parentID); err != nil {
+			return fmt.Errorf("failed to set parent %d of role %d: %w", parentID, roleID, err)
+		}
+	}
+
+	s.roleParents.removeLeft(roleID)
+	s.roleParents.add(roleID, parentIDs...)
+	return nil
+}
+
+// DenyPermissionToRole denies the permissions to the role, ErrDuplicateDeniedPermission is returned
+// if one of them is already denied. nothing is denied when an error is returned.
+func (s *Store) DenyPermissionToRole(ctx context.Context, roleID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRole(roleID); err != nil {
+		return fmt.Errorf("failed to deny permissions to role %d: %w", roleID, err)
+	}
+	if err := s.checkPermissions(permissions); err != nil {
+		return fmt.Errorf("failed to deny permissions to role %d: %w", roleID, err)
+	}
+	if _, ok := s.roleDeniedPermissions.firstDuplicate(roleID, permissions); ok {
+		return repository.ErrDuplicateDeniedPermission
+	}
+
+	s.roleDeniedPermissions.add(roleID, permissions...)
+	return nil
+}
+
+// RemoveDeniedPermissionFromRole removes the deny rules from the role, ErrPermissionNotDenied is returned
+// if one of the permissions is not denied. nothing is removed when an error is returned.
+func (s *Store) RemoveDeniedPermissionFromRole(ctx context.Context, roleID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.roleDeniedPermissions.hasAll(roleID, permissions) {
+		return repository.ErrPermissionNotDenied
+	}
+
+	s.roleDeniedPermissions.remove(roleID, permissions...)
+	return nil
+}
+
+// DenyPermissionToUser denies the permissions to the user, ErrDuplicateDeniedPermission is returned
+// if one of them is already denied. nothing is denied when an error is returned.
+func (s *Store) DenyPermissionToUser(ctx context.Context, userID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkPermissions(permissions); err != nil {
+		return fmt.Errorf("failed to deny permissions to user %d: %w", userID, err)
+	}
+	if _, ok := s.userDeniedPermissions.firstDuplicate(userID, permissions); ok {
+		return repository.ErrDuplicateDeniedPermission
+	}
+
+	s.userDeniedPermissions.add(userID, permissions...)
+	return nil
+}
+
+// RemoveDeniedPermissionFromUser removes the deny rules from the user, ErrPermissionNotDenied is returned
+// if one of the permissions is not denied. nothing is removed when an error is returned.
+func (s *Store) RemoveDeniedPermissionFromUser(ctx context.Context, userID uint, permissions []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userDeniedPermissions.hasAll(userID, permissions) {
+		return repository.ErrPermissionNotDenied
+	}
+
+	s.userDeniedPermissions.remove(userID, permissions...)
+	return nil
+}
+
+// GetDeniedPermissionsByRoleIDs returns the distinct permissions denied to any of the roles ordered by ID.
+func (s *Store) GetDeniedPermissionsByRoleIDs(ctx context.Context, roleIDs []uint) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.permissionList(s.roleDeniedPermissions.union(roleIDs)), nil
+}
+
+// GetUserDeniedPermissions returns the permissions denied directly to the user ordered by ID.
+func (s *Store) GetUserDeniedPermissions(ctx context.Context, userID uint) ([]repository.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.permissionList(s.userDeniedPermissions.rights(userID)), nil
+}
+
+// checkRole is the foreign key check of a role ID.
+func (s *Store) checkRole(roleID uint) error {
+	if _, ok := s.roles[roleID]; !ok {
+		return fmt.Errorf("role %d: %w", roleID, repository.ErrRoleNotFound)
+	}
+	return nil
+}
+
+// checkPermissions is the foreign key check of permission IDs.
+func (s *Store) checkPermissions(permissionIDs []uint) error {
+	for _, permissionID := range permissionIDs {
+		if _, ok := s.permissions[permissionID]; !ok {
+			return fmt.Errorf("permission %d: %w", permissionID, repository.ErrPermissionNotFound)
+		}
+	}
+	return nil
+}
+
+func (s *Store) roleList(roleIDs []uint) []repository.Role {
+	var roles []repository.Role
+	for _, roleID := range roleIDs {
+		roles = append(roles, repository.Role{ID: roleID, Name: s.roles[roleID]})
+	}
+	return roles
+}
+
+func (s *Store) permissionList(permissionIDs []uint) []repository.Permission {
+	var permissions []repository.Permission
+	for _, permissionID := range permissionIDs {
+		permissions = append(permissions, repository.Permission{ID: permissionID, Name: s.permissions[permissionID]})
+	}
+	return permissions
+}
+
+// lookup returns the IDs of the known names ordered by ID, each ID once.
+func lookup(ids map[string]uint, names []string) []uint {
+	found := make(map[uint]bool)
+	for _, name := range names {
+		if id, ok := ids[name]; ok {
+			found[id] = true
+		}
+	}
+	return sortedKeys(found)
+}
+
+// missingNames returns the names without an ID, see repository.MissingNames.
+func missingNames(ids map[string]uint, names []string) []string {
+	var found []string
+	for _, name := range names {
+		if _, ok := ids[name]; ok {
+			found = append(found, name)
+		}
+	}
+	return repository.MissingNames(names, found)
+}
+
+// sortedKeys returns the keys of a map indexed by ID in ascending order.
+func sortedKeys[V any](m map[uint]V) []uint {
+	var keys []uint
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
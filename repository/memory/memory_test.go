@@ -0,0 +1,149 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cangkir13/confide_acl/repository"
+	"github.com/cangkir13/confide_acl/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolesAndPermissions(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	require.NoError(t, store.CreateRole(ctx, "admin"))
+	assert.ErrorIs(t, store.CreateRole(ctx, "admin"), repository.ErrDuplicateRole)
+	require.NoError(t, store.CreatePermission(ctx, "products.get"))
+	assert.ErrorIs(t, store.CreatePermission(ctx, "products.get"), repository.ErrDuplicatePermission)
+
+	_, err := store.GetRoleIDByName(ctx, []string{"ghost"})
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+	_, err = store.GetPermissionIDByName(ctx, []string{"ghost"})
+	assert.ErrorIs(t, err, repository.ErrPermissionNotFound)
+
+	// every missing name is reported, nothing is returned for the names found
+	var notFound *repository.NotFoundError
+	ids, err := store.GetPermissionIDByName(ctx, []string{"products.get", "typo.get", "typo.put", "typo.get"})
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, []string{"typo.get", "typo.put"}, notFound.Names)
+	assert.Nil(t, ids)
+
+	require.NoError(t, store.CreateRole(ctx, "editor"))
+	assert.ErrorIs(t, store.RenameRole(ctx, "editor", "admin"), repository.ErrDuplicateRole)
+	assert.NoError(t, store.RenameRole(ctx, "editor", "author"))
+	assert.ErrorIs(t, store.RenamePermission(ctx, "ghost", "other"), repository.ErrPermissionNotFound)
+
+	roles, err := store.GetRolesByName(ctx, []string{"author", "ghost", "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Role{{ID: 1, Name: "admin"}, {ID: 2, Name: "author"}}, roles)
+
+	// IDs are not reused after a delete
+	assert.NoError(t, store.DeleteRole(ctx, "author"))
+	assert.ErrorIs(t, store.DeleteRole(ctx, "author"), repository.ErrRoleNotFound)
+	require.NoError(t, store.CreateRole(ctx, "viewer"))
+
+	roles, err = store.ListRoles(ctx, repository.Pagination{Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Role{{ID: 3, Name: "viewer"}}, roles)
+}
+
+func TestAssignments(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	for _, name := range []string{"admin", "editor"} {
+		require.NoError(t, store.CreateRole(ctx, name))
+	}
+	for _, name := range []string{"products.get", "products.post", "products.delete"} {
+		require.NoError(t, store.CreatePermission(ctx, name))
+	}
+
+	require.NoError(t, store.GivePermissionToRole(ctx, 2, []uint{1, 2}))
+
+	// a batch with an assigned permission assigns nothing
+	assert.ErrorIs(t, store.GivePermissionToRole(ctx, 2, []uint{3, 1}), repository.ErrDuplicateRolePermission)
+	assert.ErrorIs(t, store.GivePermissionToRole(ctx, 2, []uint{9}), repository.ErrPermissionNotFound)
+	assert.ErrorIs(t, store.RevokePermissionFromRole(ctx, 2, []uint{2, 3}), repository.ErrPermissionNotAssigned)
+
+	permissions, err := store.GetRolePermissions(ctx, 2, repository.Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Permission{{ID: 1, Name: "products.get"}, {ID: 2, Name: "products.post"}}, permissions)
+
+	require.NoError(t, store.GiveRoleToUser(ctx, 7, 2))
+	assert.ErrorIs(t, store.GiveRoleToUser(ctx, 7, 2), repository.ErrDuplicateUserRole)
+	assert.ErrorIs(t, store.RevokeRoleFromUser(ctx, 7, 1), repository.ErrUserRoleNotAssigned)
+
+	assert.ErrorIs(t, store.GivePermissionToUser(ctx, 7, []uint{3, 3}), repository.ErrDuplicateUserPermission)
+	require.NoError(t, store.GivePermissionToUser(ctx, 7, []uint{3}))
+	require.NoError(t, store.DenyPermissionToRole(ctx, 2, []uint{2}))
+	assert.ErrorIs(t, store.DenyPermissionToRole(ctx, 2, []uint{2}), repository.ErrDuplicateDeniedPermission)
+	assert.ErrorIs(t, store.RemoveDeniedPermissionFromUser(ctx, 7, []uint{2}), repository.ErrPermissionNotDenied)
+	require.NoError(t, store.SetRoleParents(ctx, 2, []uint{1, 1}))
+
+	accountRoles, err := store.GetAccountRolesByID(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, accountRoles, 1)
+	assert.Equal(t, "editor", accountRoles[0].RoleName)
+
+	// deleting a permission cascades to every assignment
+	require.NoError(t, store.DeletePermission(ctx, "products.post"))
+
+	permissions, err = store.GetPermissionsByRoleIDs(ctx, []uint{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Permission{{ID: 1, Name: "products.get"}}, permissions)
+
+	denied, err := store.GetDeniedPermissionsByRoleIDs(ctx, []uint{2})
+	require.NoError(t, err)
+	assert.Empty(t, denied)
+
+	// deleting a role cascades to the users and the hierarchy
+	require.NoError(t, store.DeleteRole(ctx, "admin"))
+
+	hierarchy, err := store.GetRoleHierarchy(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, hierarchy)
+
+	require.NoError(t, store.DeleteRole(ctx, "editor"))
+
+	roles, err := store.GetUserRoles(ctx, 7, repository.Pagination{})
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	users, err := store.ListUsersWithRole(ctx, 2, repository.Pagination{})
+	require.NoError(t, err)
+	assert.Nil(t, users)
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("role-%d", i)
+			if !assert.NoError(t, store.CreateRole(ctx, name)) {
+				return
+			}
+			roleIDs, err := store.GetRoleIDByName(ctx, []string{name})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, store.GiveRoleToUser(ctx, uint(i), roleIDs[0]))
+			store.ListRoles(ctx, repository.Pagination{})
+		}(i)
+	}
+	wg.Wait()
+
+	roles, err := store.ListRoles(ctx, repository.Pagination{})
+	require.NoError(t, err)
+	assert.Len(t, roles, 20)
+}
@@ -0,0 +1,91 @@
+package memory
+
+// relation is a many-to-many table like user_has_roles, indexed by the left ID.
+type relation map[uint]map[uint]bool
+
+func (r relation) has(left, right uint) bool {
+	return r[left][right]
+}
+
+// hasAll reports whether every right ID is related to left.
+func (r relation) hasAll(left uint, rights []uint) bool {
+	for _, right := range rights {
+		if !r.has(left, right) {
+			return false
+		}
+	}
+	return true
+}
+
+// firstDuplicate returns the first right ID already related to left or repeated in rights,
+// like the primary key violation of a batch of inserts.
+func (r relation) firstDuplicate(left uint, rights []uint) (uint, bool) {
+	seen := make(map[uint]bool, len(rights))
+	for _, right := range rights {
+		if r.has(left, right) || seen[right] {
+			return right, true
+		}
+		seen[right] = true
+	}
+	return 0, false
+}
+
+func (r relation) add(left uint, rights ...uint) {
+	if len(rights) == 0 {
+		return
+	}
+	if r[left] == nil {
+		r[left] = make(map[uint]bool)
+	}
+	for _, right := range rights {
+		r[left][right] = true
+	}
+}
+
+func (r relation) remove(left uint, rights ...uint) {
+	for _, right := range rights {
+		delete(r[left], right)
+	}
+	if len(r[left]) == 0 {
+		delete(r, left)
+	}
+}
+
+// removeLeft removes every relation of left, the ON DELETE CASCADE of the left table.
+func (r relation) removeLeft(left uint) {
+	delete(r, left)
+}
+
+// removeRight removes every relation to right, the ON DELETE CASCADE of the right table.
+func (r relation) removeRight(right uint) {
+	for left := range r {
+		r.remove(left, right)
+	}
+}
+
+// rights returns the IDs related to left ordered by ID.
+func (r relation) rights(left uint) []uint {
+	return sortedKeys(r[left])
+}
+
+// lefts returns the IDs related to right ordered by ID.
+func (r relation) lefts(right uint) []uint {
+	lefts := make(map[uint]bool)
+	for left, rights := range r {
+		if rights[right] {
+			lefts[left] = true
+		}
+	}
+	return sortedKeys(lefts)
+}
+
+// union returns the distinct IDs related to any of lefts ordered by ID.
+func (r relation) union(lefts []uint) []uint {
+	rights := make(map[uint]bool)
+	for _, left := range lefts {
+		for right := range r[left] {
+			rights[right] = true
+		}
+	}
+	return sortedKeys(rights)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NotFoundError is returned by the lookups by name, GetRoleIDByName and GetPermissionIDByName,
+// when some of the names do not exist. nothing is returned for the names found.
+type NotFoundError struct {
+	// Err is ErrRoleNotFound or ErrPermissionNotFound
+	Err error
+	// Names are the missing names in the order of the lookup
+	Names []string
+}
+
+// Error returns the message of the error, like "permission not found: typo.get, typo.put".
+func (e *NotFoundError) Error() string {
+	if len(e.Names) == 0 {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + ": " + strings.Join(e.Names, ", ")
+}
+
+// Unwrap returns ErrRoleNotFound or ErrPermissionNotFound, errors.Is(err, ErrRoleNotFound) matches a NotFoundError.
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// MissingNames returns the names of requested not in found, without duplicates and in the order of requested.
+func MissingNames(requested, found []string) []string {
+	exists := make(map[string]bool, len(found))
+	for _, name := range found {
+		exists[name] = true
+	}
+
+	var missing []string
+	for _, name := range uniqueNames(requested) {
+		if !exists[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// uniqueNames returns the names without duplicates, keeping the first occurrence order.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var unique []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// notFoundError builds the NotFoundError of a lookup by name in table which found less rows than names.
+// the names found are queried again to tell the missing ones, unless nothing was found.
+func (sql *SQL) notFoundError(ctx context.Context, table string, names []string, found int, notFound error) error {
+	if found == 0 {
+		return &NotFoundError{Err: notFound, Names: uniqueNames(names)}
+	}
+
+	placeholders := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		placeholders[i] = "?"
+		args[i] = name
+	}
+
+	query := fmt.Sprintf("SELECT name FROM %s WHERE name IN (%s)", table, strings.Join(placeholders, ","))
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to query names: %w", err)
+	}
+	defer rows.Close()
+
+	var existing []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan name: %w", err)
+		}
+		existing = append(existing, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return &NotFoundError{Err: notFound, Names: MissingNames(names, existing)}
+}
@@ -0,0 +1,39 @@
+package repository
+
+// Pagination limits the rows returned by the list queries.
+// A zero or negative Limit returns every row starting at Offset.
+type Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// apply appends the LIMIT/OFFSET clause of the dialect for the pagination to the query and its arguments.
+func (p Pagination) apply(dialect Dialect, query string, args []interface{}) (string, []interface{}) {
+	if p.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, p.Limit)
+	}
+	if p.Offset > 0 {
+		if p.Limit <= 0 {
+			query += dialect.offsetWithoutLimit()
+		}
+		query += " OFFSET ?"
+		args = append(args, p.Offset)
+	}
+	return query, args
+}
+
+// Paginate applies the pagination to a list computed in memory, like the LIMIT/OFFSET clause of the SQL store.
+// a zero Pagination returns every item.
+func Paginate[T any](items []T, page Pagination) []T {
+	if page.Offset > 0 {
+		if page.Offset >= len(items) {
+			return nil
+		}
+		items = items[page.Offset:]
+	}
+	if page.Limit > 0 && page.Limit < len(items) {
+		items = items[:page.Limit]
+	}
+	return items
+}
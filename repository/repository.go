@@ -9,32 +9,102 @@ import (
 )
 
 var (
-	ErrDuplicatePermission = errors.New("duplicate permission")
-	ErrDuplicateRole       = errors.New("duplicate role")
-	ErrDuplicateUserRole   = errors.New("duplicate user role")
-	ErrRoleNotFound        = errors.New("role not found")
-	ErrPermissionNotFound  = errors.New("permission not found")
-	ErrorDuplicateEntry    = "Duplicate entry"
+	ErrDuplicatePermission       = errors.New("duplicate permission")
+	ErrDuplicateRole             = errors.New("duplicate role")
+	ErrDuplicateUserRole         = errors.New("duplicate user role")
+	ErrRoleNotFound              = errors.New("role not found")
+	ErrPermissionNotFound        = errors.New("permission not found")
+	ErrPermissionNotAssigned     = errors.New("permission not assigned to role")
+	ErrUserRoleNotAssigned       = errors.New("role not assigned to user")
+	ErrDuplicateUserPermission   = errors.New("duplicate user permission")
+	ErrUserPermissionNotAssigned = errors.New("permission not assigned to user")
+	ErrDuplicateDeniedPermission = errors.New("duplicate denied permission")
+	ErrPermissionNotDenied       = errors.New("permission not denied")
+	ErrDuplicateRolePermission   = errors.New("duplicate role permission")
+	ErrorDuplicateEntry          = "Duplicate entry"
 )
 
 type SQL struct {
 	db                  *sql.DB
 	tableAccountDefault string
+	dialect             Dialect
+	tables              Tables
+	names               *strings.Replacer
 }
 
-func NewSQL(db *sql.DB, tableAccountDefault string) SQL {
-	return SQL{db: db, tableAccountDefault: tableAccountDefault}
+func NewSQL(db *sql.DB, tableAccountDefault string, opts ...Option) SQL {
+	s := SQL{db: db, tableAccountDefault: tableAccountDefault, dialect: DialectMySQL}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.tables.Account == "" {
+		s.tables.Account = tableAccountDefault
+	}
+	s.tables = s.tables.withDefaults()
+	s.tableAccountDefault = s.tables.Account
+	s.names = s.tables.replacer(s.dialect)
+	return s
+}
+
+// Validate checks the dialect and the table and column names of the repository.
+// names are quoted in the queries anyway, Validate rejects a misconfigured or injected name early.
+//
+// Returns:
+// - error: ErrUnsupportedDialect or ErrInvalidIdentifier wrapped with the invalid value, otherwise nil.
+func (s *SQL) Validate() error {
+	if err := s.dialect.validate(); err != nil {
+		return err
+	}
+	return s.tables.validate()
 }
 
+// render replaces the {table} and {column} names of a query with the mapped names
+// and rewrites its placeholders for the dialect.
+func (s *SQL) render(query string) string {
+	return s.dialect.Rebind(s.names.Replace(query))
+}
+
+// SQL must keep implementing RepositoryService
+var _ RepositoryService = (*SQL)(nil)
+
+// RepositoryService is the storage used by the confide_acl service, SQL implements it.
+// a custom implementation can be set in confide_acl.ConfigACL.Store, it must return the
+// sentinel errors of this package (ErrRoleNotFound, ErrDuplicateRole, ...) in the same cases as SQL,
+// GetRoleIDByName and GetPermissionIDByName return a *NotFoundError when one of the names does not exist.
 type RepositoryService interface {
 	CreateRole(ctx context.Context, name string) error
 	CreatePermission(ctx context.Context, name string) error
 	GetAccountHasPermission(ctx context.Context, userid uint, ps []string) ([]Permission, error)
-	GetAccountHasRolePermissions(ctx context.Context, userid uint, roleID []uint) (RoleHasPermissions, error)
+	GetAccountRolesByID(ctx context.Context, userID uint) ([]AccountRole, error)
 	GetPermissionIDByName(ctx context.Context, permissions []string) ([]uint, error)
 	GetRoleIDByName(ctx context.Context, names []string) ([]uint, error)
+	GetRolesByName(ctx context.Context, names []string) ([]Role, error)
 	GivePermissionToRole(ctx context.Context, roleID uint, permissions []uint) error
 	GiveRoleToUser(ctx context.Context, userID uint, roleID uint) error
+	RevokePermissionFromRole(ctx context.Context, roleID uint, permissions []uint) error
+	RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error
+	GivePermissionToUser(ctx context.Context, userID uint, permissions []uint) error
+	RevokePermissionFromUser(ctx context.Context, userID uint, permissions []uint) error
+	DeleteRole(ctx context.Context, name string) error
+	DeletePermission(ctx context.Context, name string) error
+	RenameRole(ctx context.Context, name, newName string) error
+	RenamePermission(ctx context.Context, name, newName string) error
+	ListRoles(ctx context.Context, page Pagination) ([]Role, error)
+	ListPermissions(ctx context.Context, page Pagination) ([]Permission, error)
+	GetRolePermissions(ctx context.Context, roleID uint, page Pagination) ([]Permission, error)
+	GetUserRoles(ctx context.Context, userID uint, page Pagination) ([]Role, error)
+	GetUserDirectPermissions(ctx context.Context, userID uint, page Pagination) ([]Permission, error)
+	ListUsersWithRole(ctx context.Context, roleID uint, page Pagination) ([]uint, error)
+	GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uint) ([]Permission, error)
+	GetRoleHierarchy(ctx context.Context) ([]RoleHasRole, error)
+	SetRoleParents(ctx context.Context, roleID uint, parentIDs []uint) error
+	DenyPermissionToRole(ctx context.Context, roleID uint, permissions []uint) error
+	RemoveDeniedPermissionFromRole(ctx context.Context, roleID uint, permissions []uint) error
+	DenyPermissionToUser(ctx context.Context, userID uint, permissions []uint) error
+	RemoveDeniedPermissionFromUser(ctx context.Context, userID uint, permissions []uint) error
+	GetDeniedPermissionsByRoleIDs(ctx context.Context, roleIDs []uint) ([]Permission, error)
+	GetUserDeniedPermissions(ctx context.Context, userID uint) ([]Permission, error)
 }
 
 // CreateRole inserts a new role into the database with the given name.
@@ -46,11 +116,11 @@ type RepositoryService interface {
 // Returns:
 // - error: An error if the role creation fails, otherwise nil.
 func (sql *SQL) CreateRole(ctx context.Context, name string) error {
-	query := "INSERT INTO roles (name) VALUES (?)"
+	query := "INSERT INTO {roles} (name) VALUES (?)"
 
-	_, err := sql.db.ExecContext(ctx, query, name)
+	_, err := sql.db.ExecContext(ctx, sql.render(query), name)
 	if err != nil {
-		if strings.Contains(err.Error(), ErrorDuplicateEntry) {
+		if sql.dialect.isUniqueViolation(err) {
 			return ErrDuplicateRole
 		}
 		return fmt.Errorf("failed to create role with name %s: %w", name, err)
@@ -67,11 +137,11 @@ func (sql *SQL) CreateRole(ctx context.Context, name string) error {
 // Returns:
 // - error: An error if the permission creation fails, otherwise nil.
 func (sql *SQL) CreatePermission(ctx context.Context, name string) error {
-	query := "INSERT INTO permissions (name) VALUES (?)"
+	query := "INSERT INTO {permissions} (name) VALUES (?)"
 
-	_, err := sql.db.ExecContext(ctx, query, name)
+	_, err := sql.db.ExecContext(ctx, sql.render(query), name)
 	if err != nil {
-		if strings.Contains(err.Error(), ErrorDuplicateEntry) {
+		if sql.dialect.isUniqueViolation(err) {
 			return ErrDuplicatePermission
 		}
 		return fmt.Errorf("failed to create permission with name %s: %w", name, err)
@@ -79,6 +149,122 @@ func (sql *SQL) CreatePermission(ctx context.Context, name string) error {
 	return nil
 }
 
+// DeleteRole removes the role with the given name from the database.
+// Assignments in role_has_permissions and user_has_roles are removed by the ON DELETE CASCADE foreign keys.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The name of the role to be deleted.
+//
+// Returns:
+// - error: ErrRoleNotFound if the role does not exist, an error if the deletion fails, otherwise nil.
+func (sql *SQL) DeleteRole(ctx context.Context, name string) error {
+	query := "DELETE FROM {roles} WHERE name = ?"
+
+	result, err := sql.execWithForeignKeys(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete role with name %s: %w", name, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete role with name %s: %w", name, err)
+	}
+	if affected == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// DeletePermission removes the permission with the given name from the database.
+// Assignments in role_has_permissions and user_has_permissions are removed by the ON DELETE CASCADE foreign keys.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The name of the permission to be deleted.
+//
+// Returns:
+// - error: ErrPermissionNotFound if the permission does not exist, an error if the deletion fails, otherwise nil.
+func (sql *SQL) DeletePermission(ctx context.Context, name string) error {
+	query := "DELETE FROM {permissions} WHERE name = ?"
+
+	result, err := sql.execWithForeignKeys(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete permission with name %s: %w", name, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete permission with name %s: %w", name, err)
+	}
+	if affected == 0 {
+		return ErrPermissionNotFound
+	}
+	return nil
+}
+
+// RenameRole changes the name of an existing role.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The current name of the role.
+// - newName: The new name of the role.
+//
+// Returns:
+// - error: ErrRoleNotFound if the role does not exist, ErrDuplicateRole if newName is already taken,
+// an error if the update fails, otherwise nil.
+func (sql *SQL) RenameRole(ctx context.Context, name, newName string) error {
+	query := "UPDATE {roles} SET name = ? WHERE name = ?"
+
+	result, err := sql.db.ExecContext(ctx, sql.render(query), newName, name)
+	if err != nil {
+		if sql.dialect.isUniqueViolation(err) {
+			return ErrDuplicateRole
+		}
+		return fmt.Errorf("failed to rename role %s to %s: %w", name, newName, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to rename role %s to %s: %w", name, newName, err)
+	}
+	if affected == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// RenamePermission changes the name of an existing permission.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The current name of the permission.
+// - newName: The new name of the permission.
+//
+// Returns:
+// - error: ErrPermissionNotFound if the permission does not exist, ErrDuplicatePermission if newName is already taken,
+// an error if the update fails, otherwise nil.
+func (sql *SQL) RenamePermission(ctx context.Context, name, newName string) error {
+	query := "UPDATE {permissions} SET name = ? WHERE name = ?"
+
+	result, err := sql.db.ExecContext(ctx, sql.render(query), newName, name)
+	if err != nil {
+		if sql.dialect.isUniqueViolation(err) {
+			return ErrDuplicatePermission
+		}
+		return fmt.Errorf("failed to rename permission %s to %s: %w", name, newName, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to rename permission %s to %s: %w", name, newName, err)
+	}
+	if affected == 0 {
+		return ErrPermissionNotFound
+	}
+	return nil
+}
+
 // GetAccountRoleByID retrieves the account role associated with a user from the SQL database based on the user ID.
 //
 // Parameters:
@@ -92,19 +278,19 @@ func (s *SQL) GetAccountRoleByID(ctx context.Context, userID uint) (AccountRole,
 	var accountRole AccountRole
 	query := `
 		SELECT 
-			a.full_name AS fullName, 
+			a.{account_name} AS fullName, 
 			r.name AS roleName 
 		FROM 
-			user_has_roles ur 
+			{user_has_roles} ur 
 		JOIN 
-			` + s.tableAccountDefault + ` a ON ur.user_id = a.id 
+			{account} a ON ur.{user_id} = a.{account_id} 
 		JOIN 
-			roles r ON ur.role_id = r.id 
+			{roles} r ON ur.role_id = r.id 
 		WHERE 
-			a.id = ?
+			a.{account_id} = ?
 	`
 
-	err := s.db.QueryRowContext(ctx, query, userID).Scan(&accountRole.FullName, &accountRole.RoleName)
+	err := s.db.QueryRowContext(ctx, s.render(query), userID).Scan(&accountRole.FullName, &accountRole.RoleName)
 	if err != nil && err != sql.ErrNoRows {
 		return accountRole, err
 	}
@@ -112,6 +298,55 @@ func (s *SQL) GetAccountRoleByID(ctx context.Context, userID uint) (AccountRole,
 	return accountRole, nil
 }
 
+// GetAccountRolesByID retrieves every role associated with a user from the SQL database based on the user ID.
+// Unlike GetAccountRoleByID, which only reads the first row, one AccountRole is returned per row in user_has_roles.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user for whom the account roles are being retrieved.
+//
+// Returns:
+// - []AccountRole: The account roles associated with the user, empty if the user holds no role.
+// - error: An error if the retrieval fails, otherwise nil.
+func (s *SQL) GetAccountRolesByID(ctx context.Context, userID uint) ([]AccountRole, error) {
+	var accountRoles []AccountRole
+	query := `
+		SELECT 
+			a.{account_name} AS fullName, 
+			r.name AS roleName 
+		FROM 
+			{user_has_roles} ur 
+		JOIN 
+			{account} a ON ur.{user_id} = a.{account_id} 
+		JOIN 
+			{roles} r ON ur.role_id = r.id 
+		WHERE 
+			a.{account_id} = ?
+		ORDER BY 
+			r.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, s.render(query), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles of account %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountRole AccountRole
+		if err := rows.Scan(&accountRole.FullName, &accountRole.RoleName); err != nil {
+			return nil, fmt.Errorf("failed to scan account role: %w", err)
+		}
+		accountRoles = append(accountRoles, accountRole)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return accountRoles, nil
+}
+
 // GetPermissionIDByName retrieves the permission IDs from the database based on the provided permission names.
 //
 // Parameters:
@@ -120,10 +355,16 @@ func (s *SQL) GetAccountRoleByID(ctx context.Context, userID uint) (AccountRole,
 //
 // Returns:
 // - []uint: A slice of uint representing the permission IDs.
-// - error: An error if the query fails, otherwise nil.
+// - error: A *NotFoundError matching ErrPermissionNotFound and listing the missing names if one of the
+// permissions does not exist or the list is empty, an error if the query fails, otherwise nil.
 func (sql *SQL) GetPermissionIDByName(ctx context.Context, permissions []string) ([]uint, error) {
 	var permissionIDs []uint
 
+	// an empty list can't be queried, "IN ()" is a syntax error
+	if len(permissions) == 0 {
+		return nil, &NotFoundError{Err: ErrPermissionNotFound}
+	}
+
 	// Bangun query SQL dengan placeholder untuk setiap permission name
 	placeholders := make([]string, len(permissions))
 	args := make([]interface{}, len(permissions))
@@ -133,11 +374,11 @@ func (sql *SQL) GetPermissionIDByName(ctx context.Context, permissions []string)
 		args[i] = perm
 	}
 
-	query := fmt.Sprintf("SELECT id FROM permissions WHERE name IN (%s)",
+	query := fmt.Sprintf("SELECT id FROM {permissions} WHERE name IN (%s)",
 		strings.Join(placeholders, ","))
 
 	// Eksekusi query dan proses hasil
-	rows, err := sql.db.QueryContext(ctx, query, args...)
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query permissions: %w", err)
 	}
@@ -155,8 +396,8 @@ func (sql *SQL) GetPermissionIDByName(ctx context.Context, permissions []string)
 		return nil, fmt.Errorf("error iterating through rows: %w", err)
 	}
 
-	if len(permissionIDs) == 0 {
-		return nil, ErrPermissionNotFound
+	if len(permissionIDs) == 0 || len(permissionIDs) < len(uniqueNames(permissions)) {
+		return nil, sql.notFoundError(ctx, "{permissions}", permissions, len(permissionIDs), ErrPermissionNotFound)
 	}
 
 	return permissionIDs, nil
@@ -170,10 +411,16 @@ func (sql *SQL) GetPermissionIDByName(ctx context.Context, permissions []string)
 //
 // Returns:
 // - []uint: A slice of uint representing the role IDs.
-// - error: An error if the query fails, otherwise nil.
+// - error: A *NotFoundError matching ErrRoleNotFound and listing the missing names if one of the
+// roles does not exist or the list is empty, an error if the query fails, otherwise nil.
 func (sql *SQL) GetRoleIDByName(ctx context.Context, roles []string) ([]uint, error) {
 	var roleIDs []uint
 
+	// an empty list can't be queried, "IN ()" is a syntax error
+	if len(roles) == 0 {
+		return nil, &NotFoundError{Err: ErrRoleNotFound}
+	}
+
 	// Bangun query SQL dengan placeholder untuk setiap role name
 	placeholders := make([]string, len(roles))
 	args := make([]interface{}, len(roles))
@@ -183,11 +430,11 @@ func (sql *SQL) GetRoleIDByName(ctx context.Context, roles []string) ([]uint, er
 		args[i] = role
 	}
 
-	query := fmt.Sprintf("SELECT id FROM roles WHERE name IN (%s)",
+	query := fmt.Sprintf("SELECT id FROM {roles} WHERE name IN (%s)",
 		strings.Join(placeholders, ","))
 
 	// Eksekusi query dan proses hasil
-	rows, err := sql.db.QueryContext(ctx, query, args...)
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query roles: %w", err)
 	}
@@ -205,13 +452,45 @@ func (sql *SQL) GetRoleIDByName(ctx context.Context, roles []string) ([]uint, er
 		return nil, fmt.Errorf("error iterating through rows: %w", err)
 	}
 
-	if len(roleIDs) == 0 {
-		return nil, ErrRoleNotFound
+	if len(roleIDs) == 0 || len(roleIDs) < len(uniqueNames(roles)) {
+		return nil, sql.notFoundError(ctx, "{roles}", roles, len(roleIDs), ErrRoleNotFound)
 	}
 
 	return roleIDs, nil
 }
 
+// GetRolesByName retrieves the roles matching the given names ordered by ID.
+// Unlike GetRoleIDByName, unknown names are ignored.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - names: A slice of strings representing the role names.
+//
+// Returns:
+// - []Role: A slice of Role structs, empty when no role matches.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetRolesByName(ctx context.Context, names []string) ([]Role, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(names))
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+
+	query := fmt.Sprintf("SELECT id, name FROM {roles} WHERE name IN (%s) ORDER BY id",
+		strings.Join(placeholders, ","))
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), convertStringSliceToInterfaceSlice(names)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
 // GetAccountHasPermission retrieves the permissions associated with a user from the SQL database.
 //
 // Description:
@@ -230,9 +509,9 @@ func (s *SQL) GetAccountHasPermission(ctx context.Context, userid uint, ps []str
 	var permissions []Permission
 
 	baseQuery := `SELECT p.id, p.name
-				FROM user_has_permissions uhp
-				JOIN permissions p ON uhp.permission_id = p.id
-				WHERE uhp.user_id = ?`
+				FROM {user_has_permissions} uhp
+				JOIN {permissions} p ON uhp.permission_id = p.id
+				WHERE uhp.{user_id} = ?`
 
 	// Prepare query based on roles length
 	var query string
@@ -249,7 +528,7 @@ func (s *SQL) GetAccountHasPermission(ctx context.Context, userid uint, ps []str
 	args = append(args, convertStringSliceToInterfaceSlice(ps)...)
 
 	// execute query
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	rows, err := s.db.QueryContext(ctx, s.render(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -281,11 +560,11 @@ func (sql *SQL) GetAccountHasRolePermissions(ctx context.Context, userid uint, r
 	var permissions []Permission
 
 	baseQuery := `SELECT r.id, p.id, p.name
-				FROM user_has_roles ur
-				JOIN roles r ON ur.role_id = r.id
-				JOIN role_has_permissions rhp ON rhp.role_id = r.id
-				JOIN permissions p ON rhp.permission_id = p.id
-				WHERE ur.user_id = ? `
+				FROM {user_has_roles} ur
+				JOIN {roles} r ON ur.role_id = r.id
+				JOIN {role_has_permissions} rhp ON rhp.role_id = r.id
+				JOIN {permissions} p ON rhp.permission_id = p.id
+				WHERE ur.{user_id} = ? `
 
 	// Prepare query based on roles length
 	var query string
@@ -302,7 +581,7 @@ func (sql *SQL) GetAccountHasRolePermissions(ctx context.Context, userid uint, r
 	args = append(args, convertStringSliceToInterfaceSlice(roles)...)
 
 	// Execute query
-	rows, err := sql.db.QueryContext(ctx, query, args...)
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
 	if err != nil {
 		return rolePermissions, err
 	}
@@ -338,7 +617,7 @@ func (sql *SQL) GetAccountHasRolePermissions(ctx context.Context, userid uint, r
 // - permissions: A slice of uint representing the IDs of the permissions to be assigned.
 //
 // Returns:
-// - error: An error if the assignment fails, otherwise nil.
+// - error: ErrDuplicateRolePermission if the role already has one of the permissions, an error if the assignment fails, otherwise nil.
 func (sql *SQL) GivePermissionToRole(ctx context.Context, roleID uint, permissions []uint) error {
 	// Mulai transaksi
 	tx, err := sql.db.BeginTx(ctx, nil)
@@ -347,11 +626,14 @@ func (sql *SQL) GivePermissionToRole(ctx context.Context, roleID uint, permissio
 	}
 
 	// Persiapkan query untuk memasukkan izin
-	query := "INSERT INTO role_has_permissions (role_id, permission_id) VALUES (?, ?)"
+	query := "INSERT INTO {role_has_permissions} (role_id, permission_id) VALUES (?, ?)"
 	for _, permissionID := range permissions {
-		_, err := tx.ExecContext(ctx, query, roleID, permissionID)
+		_, err := tx.ExecContext(ctx, sql.render(query), roleID, permissionID)
 		if err != nil {
 			tx.Rollback()
+			if sql.dialect.isUniqueViolation(err) {
+				return ErrDuplicateRolePermission
+			}
 			return fmt.Errorf("failed to assign permission %d to role %d: %w", permissionID, roleID, err)
 		}
 	}
@@ -375,11 +657,11 @@ func (sql *SQL) GivePermissionToRole(ctx context.Context, roleID uint, permissio
 // Returns:
 // - error: An error if the assignment fails, otherwise nil.
 func (sql *SQL) GiveRoleToUser(ctx context.Context, userID uint, role uint) error {
-	query := "INSERT INTO user_has_roles (user_id, role_id) VALUES (?, ?)"
+	query := "INSERT INTO {user_has_roles} ({user_id}, role_id) VALUES (?, ?)"
 
-	_, err := sql.db.ExecContext(ctx, query, userID, role)
+	_, err := sql.db.ExecContext(ctx, sql.render(query), userID, role)
 	if err != nil {
-		if strings.Contains(err.Error(), "Duplicate entry") {
+		if sql.dialect.isUniqueViolation(err) {
 			return ErrDuplicateUserRole
 		}
 		return fmt.Errorf("failed to assign role %d to user %d: %w", role, userID, err)
@@ -387,6 +669,677 @@ func (sql *SQL) GiveRoleToUser(ctx context.Context, userID uint, role uint) erro
 	return nil
 }
 
+// RevokePermissionFromRole removes a list of permissions from a role in the SQL database.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleID: The ID of the role from which the permissions will be revoked.
+// - permissions: A slice of uint representing the IDs of the permissions to be revoked.
+//
+// Returns:
+// - error: ErrPermissionNotAssigned if one of the permissions is not assigned to the role,
+// an error if the revocation fails, otherwise nil. Nothing is revoked when an error is returned.
+func (sql *SQL) RevokePermissionFromRole(ctx context.Context, roleID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "DELETE FROM {role_has_permissions} WHERE role_id = ? AND permission_id = ?"
+	for _, permissionID := range permissions {
+		result, err := tx.ExecContext(ctx, sql.render(query), roleID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to revoke permission %d from role %d: %w", permissionID, roleID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to revoke permission %d from role %d: %w", permissionID, roleID, err)
+		}
+		if affected == 0 {
+			tx.Rollback()
+			return ErrPermissionNotAssigned
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeRoleFromUser removes a role from a user in the database.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user from whom the role will be removed.
+// - role: The ID of the role to be removed from the user.
+//
+// Returns:
+// - error: ErrUserRoleNotAssigned if the user does not hold the role, an error if the removal fails, otherwise nil.
+func (sql *SQL) RevokeRoleFromUser(ctx context.Context, userID uint, role uint) error {
+	query := "DELETE FROM {user_has_roles} WHERE {user_id} = ? AND role_id = ?"
+
+	result, err := sql.db.ExecContext(ctx, sql.render(query), userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to remove role %d from user %d: %w", role, userID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove role %d from user %d: %w", role, userID, err)
+	}
+	if affected == 0 {
+		return ErrUserRoleNotAssigned
+	}
+	return nil
+}
+
+// GivePermissionToUser assigns a list of permissions directly to a user in the SQL database.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user to whom the permissions will be assigned.
+// - permissions: A slice of uint representing the IDs of the permissions to be assigned.
+//
+// Returns:
+// - error: ErrDuplicateUserPermission if the user already has one of the permissions,
+// an error if the assignment fails, otherwise nil. Nothing is assigned when an error is returned.
+func (sql *SQL) GivePermissionToUser(ctx context.Context, userID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "INSERT INTO {user_has_permissions} ({user_id}, permission_id) VALUES (?, ?)"
+	for _, permissionID := range permissions {
+		_, err := tx.ExecContext(ctx, sql.render(query), userID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			if sql.dialect.isUniqueViolation(err) {
+				return ErrDuplicateUserPermission
+			}
+			return fmt.Errorf("failed to assign permission %d to user %d: %w", permissionID, userID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RevokePermissionFromUser removes a list of directly assigned permissions from a user in the SQL database.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user from whom the permissions will be revoked.
+// - permissions: A slice of uint representing the IDs of the permissions to be revoked.
+//
+// Returns:
+// - error: ErrUserPermissionNotAssigned if one of the permissions is not assigned to the user,
+// an error if the revocation fails, otherwise nil. Nothing is revoked when an error is returned.
+func (sql *SQL) RevokePermissionFromUser(ctx context.Context, userID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "DELETE FROM {user_has_permissions} WHERE {user_id} = ? AND permission_id = ?"
+	for _, permissionID := range permissions {
+		result, err := tx.ExecContext(ctx, sql.render(query), userID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to revoke permission %d from user %d: %w", permissionID, userID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to revoke permission %d from user %d: %w", permissionID, userID, err)
+		}
+		if affected == 0 {
+			tx.Rollback()
+			return ErrUserPermissionNotAssigned
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListRoles retrieves the roles stored in the database ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - page: The pagination applied to the result.
+//
+// Returns:
+// - []Role: A slice of Role structs.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) ListRoles(ctx context.Context, page Pagination) ([]Role, error) {
+	query, args := page.apply(sql.dialect, "SELECT id, name FROM {roles} ORDER BY id", nil)
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// ListPermissions retrieves the permissions stored in the database ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - page: The pagination applied to the result.
+//
+// Returns:
+// - []Permission: A slice of Permission structs.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) ListPermissions(ctx context.Context, page Pagination) ([]Permission, error) {
+	query, args := page.apply(sql.dialect, "SELECT id, name FROM {permissions} ORDER BY id", nil)
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query permissions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
+// GetRolePermissions retrieves the permissions assigned to a role ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleID: The ID of the role.
+// - page: The pagination applied to the result.
+//
+// Returns:
+// - []Permission: A slice of Permission structs assigned to the role.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetRolePermissions(ctx context.Context, roleID uint, page Pagination) ([]Permission, error) {
+	query, args := page.apply(sql.dialect, `SELECT p.id, p.name
+				FROM {role_has_permissions} rhp
+				JOIN {permissions} p ON rhp.permission_id = p.id
+				WHERE rhp.role_id = ?
+				ORDER BY p.id`, []interface{}{roleID})
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query permissions of role %d: %w", roleID, err)
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
+// GetUserRoles retrieves the roles held by a user ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user.
+// - page: The pagination applied to the result.
+//
+// Returns:
+// - []Role: A slice of Role structs held by the user.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetUserRoles(ctx context.Context, userID uint, page Pagination) ([]Role, error) {
+	query, args := page.apply(sql.dialect, `SELECT r.id, r.name
+				FROM {user_has_roles} ur
+				JOIN {roles} r ON ur.role_id = r.id
+				WHERE ur.{user_id} = ?
+				ORDER BY r.id`, []interface{}{userID})
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// GetUserDirectPermissions retrieves the permissions assigned directly to a user in
+// user_has_permissions ordered by ID. Permissions granted through roles are not included.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user.
+// - page: The pagination applied to the result.
+//
+// Returns:
+// - []Permission: A slice of Permission structs assigned to the user.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetUserDirectPermissions(ctx context.Context, userID uint, page Pagination) ([]Permission, error) {
+	query, args := page.apply(sql.dialect, `SELECT p.id, p.name
+				FROM {user_has_permissions} uhp
+				JOIN {permissions} p ON uhp.permission_id = p.id
+				WHERE uhp.{user_id} = ?
+				ORDER BY p.id`, []interface{}{userID})
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query direct permissions of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
+// ListUsersWithRole retrieves the IDs of the users holding a role ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleID: The ID of the role.
+// - page: The pagination applied to the result.
+//
+// Returns:
+// - []uint: A slice of uint representing the user IDs.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) ListUsersWithRole(ctx context.Context, roleID uint, page Pagination) ([]uint, error) {
+	query, args := page.apply(sql.dialect, "SELECT {user_id} FROM {user_has_roles} WHERE role_id = ? ORDER BY {user_id}", []interface{}{roleID})
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users of role %d: %w", roleID, err)
+	}
+	defer rows.Close()
+
+	var userIDs []uint
+	for rows.Next() {
+		var userID uint
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// GetPermissionsByRoleIDs retrieves the distinct permissions assigned to any of the given roles ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleIDs: A slice of uint representing the IDs of the roles.
+//
+// Returns:
+// - []Permission: A slice of Permission structs assigned to the roles, empty when roleIDs is empty.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetPermissionsByRoleIDs(ctx context.Context, roleIDs []uint) ([]Permission, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(roleIDs))
+	args := make([]interface{}, len(roleIDs))
+	for i, roleID := range roleIDs {
+		placeholders[i] = "?"
+		args[i] = roleID
+	}
+
+	query := fmt.Sprintf(`SELECT DISTINCT p.id, p.name
+				FROM {role_has_permissions} rhp
+				JOIN {permissions} p ON rhp.permission_id = p.id
+				WHERE rhp.role_id IN (%s)
+				ORDER BY p.id`, strings.Join(placeholders, ","))
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query permissions of roles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
+// GetRoleHierarchy retrieves every parent relation stored in role_has_roles.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+//
+// Returns:
+// - []RoleHasRole: A slice of RoleHasRole structs, one per role and parent pair.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetRoleHierarchy(ctx context.Context) ([]RoleHasRole, error) {
+	query := "SELECT role_id, parent_role_id FROM {role_has_roles} ORDER BY role_id, parent_role_id"
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	var hierarchy []RoleHasRole
+	for rows.Next() {
+		var relation RoleHasRole
+		if err := rows.Scan(&relation.RoleID, &relation.ParentRoleID); err != nil {
+			return nil, fmt.Errorf("failed to scan role hierarchy: %w", err)
+		}
+		hierarchy = append(hierarchy, relation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return hierarchy, nil
+}
+
+// SetRoleParents replaces the parents of a role in role_has_roles.
+// The role inherits the permissions of its parents. Cycles are not checked here.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleID: The ID of the role.
+// - parentIDs: A slice of uint representing the IDs of the parent roles, an empty slice removes every parent.
+//
+// Returns:
+// - error: An error if the update fails, otherwise nil. The parents are left untouched when an error is returned.
+func (sql *SQL) SetRoleParents(ctx context.Context, roleID uint, parentIDs []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sql.render("DELETE FROM {role_has_roles} WHERE role_id = ?"), roleID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to remove parents of role %d: %w", roleID, err)
+	}
+
+	// duplicated parents are stored once
+	query := sql.dialect.insertIgnore("{role_has_roles}", "role_id", "parent_role_id")
+	for _, parentID := range parentIDs {
+		_, err := tx.ExecContext(ctx, sql.render(query), roleID, parentID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to set parent %d of role %d: %w", parentID, roleID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DenyPermissionToRole denies a list of permissions to a role in the SQL database.
+// A denied permission overrides every grant of the role.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleID: The ID of the role to whom the permissions will be denied.
+// - permissions: A slice of uint representing the IDs of the permissions to be denied.
+//
+// Returns:
+// - error: ErrDuplicateDeniedPermission if one of the permissions is already denied,
+// an error if the insertion fails, otherwise nil. Nothing is denied when an error is returned.
+func (sql *SQL) DenyPermissionToRole(ctx context.Context, roleID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "INSERT INTO {role_has_denied_permissions} (role_id, permission_id) VALUES (?, ?)"
+	for _, permissionID := range permissions {
+		_, err := tx.ExecContext(ctx, sql.render(query), roleID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			if sql.dialect.isUniqueViolation(err) {
+				return ErrDuplicateDeniedPermission
+			}
+			return fmt.Errorf("failed to deny permission %d to role %d: %w", permissionID, roleID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveDeniedPermissionFromRole removes a list of denied permissions from a role in the SQL database.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleID: The ID of the role.
+// - permissions: A slice of uint representing the IDs of the denied permissions to be removed.
+//
+// Returns:
+// - error: ErrPermissionNotDenied if one of the permissions is not denied to the role,
+// an error if the removal fails, otherwise nil. Nothing is removed when an error is returned.
+func (sql *SQL) RemoveDeniedPermissionFromRole(ctx context.Context, roleID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "DELETE FROM {role_has_denied_permissions} WHERE role_id = ? AND permission_id = ?"
+	for _, permissionID := range permissions {
+		result, err := tx.ExecContext(ctx, sql.render(query), roleID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove denied permission %d from role %d: %w", permissionID, roleID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove denied permission %d from role %d: %w", permissionID, roleID, err)
+		}
+		if affected == 0 {
+			tx.Rollback()
+			return ErrPermissionNotDenied
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DenyPermissionToUser denies a list of permissions to a user in the SQL database.
+// A denied permission overrides every grant of the user.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user to whom the permissions will be denied.
+// - permissions: A slice of uint representing the IDs of the permissions to be denied.
+//
+// Returns:
+// - error: ErrDuplicateDeniedPermission if one of the permissions is already denied,
+// an error if the insertion fails, otherwise nil. Nothing is denied when an error is returned.
+func (sql *SQL) DenyPermissionToUser(ctx context.Context, userID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "INSERT INTO {user_has_denied_permissions} ({user_id}, permission_id) VALUES (?, ?)"
+	for _, permissionID := range permissions {
+		_, err := tx.ExecContext(ctx, sql.render(query), userID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			if sql.dialect.isUniqueViolation(err) {
+				return ErrDuplicateDeniedPermission
+			}
+			return fmt.Errorf("failed to deny permission %d to user %d: %w", permissionID, userID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveDeniedPermissionFromUser removes a list of denied permissions from a user in the SQL database.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user.
+// - permissions: A slice of uint representing the IDs of the denied permissions to be removed.
+//
+// Returns:
+// - error: ErrPermissionNotDenied if one of the permissions is not denied to the user,
+// an error if the removal fails, otherwise nil. Nothing is removed when an error is returned.
+func (sql *SQL) RemoveDeniedPermissionFromUser(ctx context.Context, userID uint, permissions []uint) error {
+	tx, err := sql.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	query := "DELETE FROM {user_has_denied_permissions} WHERE {user_id} = ? AND permission_id = ?"
+	for _, permissionID := range permissions {
+		result, err := tx.ExecContext(ctx, sql.render(query), userID, permissionID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove denied permission %d from user %d: %w", permissionID, userID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove denied permission %d from user %d: %w", permissionID, userID, err)
+		}
+		if affected == 0 {
+			tx.Rollback()
+			return ErrPermissionNotDenied
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeniedPermissionsByRoleIDs retrieves the distinct permissions denied to any of the given roles ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - roleIDs: A slice of uint representing the IDs of the roles.
+//
+// Returns:
+// - []Permission: A slice of Permission structs denied to the roles, empty when roleIDs is empty.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetDeniedPermissionsByRoleIDs(ctx context.Context, roleIDs []uint) ([]Permission, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(roleIDs))
+	args := make([]interface{}, len(roleIDs))
+	for i, roleID := range roleIDs {
+		placeholders[i] = "?"
+		args[i] = roleID
+	}
+
+	query := fmt.Sprintf(`SELECT DISTINCT p.id, p.name
+				FROM {role_has_denied_permissions} rdp
+				JOIN {permissions} p ON rdp.permission_id = p.id
+				WHERE rdp.role_id IN (%s)
+				ORDER BY p.id`, strings.Join(placeholders, ","))
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query denied permissions of roles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
+// GetUserDeniedPermissions retrieves the permissions denied directly to a user ordered by ID.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user.
+//
+// Returns:
+// - []Permission: A slice of Permission structs denied to the user.
+// - error: An error if the query fails, otherwise nil.
+func (sql *SQL) GetUserDeniedPermissions(ctx context.Context, userID uint) ([]Permission, error) {
+	query := `SELECT p.id, p.name
+				FROM {user_has_denied_permissions} udp
+				JOIN {permissions} p ON udp.permission_id = p.id
+				WHERE udp.{user_id} = ?
+				ORDER BY p.id`
+
+	rows, err := sql.db.QueryContext(ctx, sql.render(query), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query denied permissions of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	return scanPermissions(rows)
+}
+
+// Helper function to scan id, name rows into roles
+func scanRoles(rows *sql.Rows) ([]Role, error) {
+	var roles []Role
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// Helper function to scan id, name rows into permissions
+func scanPermissions(rows *sql.Rows) ([]Permission, error) {
+	var permissions []Permission
+	for rows.Next() {
+		var permission Permission
+		if err := rows.Scan(&permission.ID, &permission.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
 // Helper function to convert []sting to []interface{}
 func convertStringSliceToInterfaceSlice(slice []string) []interface{} {
 	result := make([]interface{}, len(slice))
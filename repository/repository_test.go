@@ -3,6 +3,8 @@ package repository_test
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -189,6 +191,49 @@ func TestGivePermissionToRole(t *testing.T) {
 	}
 }
 
+func TestGivePermissionToRoleDuplicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect repository.Dialect
+		query   string
+		err     error
+	}{
+		{"mysql", repository.DialectMySQL, "INSERT INTO role_has_permissions (role_id, permission_id) VALUES (?, ?)",
+			errors.New("Error 1062: Duplicate entry '1-2' for key 'PRIMARY'")},
+		{"postgres", repository.DialectPostgres, "INSERT INTO role_has_permissions (role_id, permission_id) VALUES ($1, $2)",
+			&pgError{code: "23505"}},
+		{"sqlite", repository.DialectSQLite, "INSERT INTO role_has_permissions (role_id, permission_id) VALUES (?, ?)",
+			errors.New("UNIQUE constraint failed: role_has_permissions.role_id, role_has_permissions.permission_id")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			repo := repository.NewSQL(db, tableuser, repository.WithDialect(tt.dialect))
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(tt.query)).
+				WithArgs(1, 2).
+				WillReturnError(tt.err)
+			mock.ExpectRollback()
+
+			err = repo.GivePermissionToRole(context.Background(), 1, []uint{2})
+			if !errors.Is(err, repository.ErrDuplicateRolePermission) {
+				t.Errorf("expected %v, got %v", repository.ErrDuplicateRolePermission, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
 func TestGiveRoleToUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -253,6 +298,111 @@ func TestGetRoleIDByName(t *testing.T) {
 	}
 }
 
+func TestGetPermissionIDByNameMissing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+	ctx := context.Background()
+
+	// one of the names exists, the names are queried to tell the missing one
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM permissions WHERE name IN (?,?)")).
+		WithArgs("articles.get", "typo.get").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT name FROM permissions WHERE name IN (?,?)")).
+		WithArgs("articles.get", "typo.get").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("articles.get"))
+
+	ids, err := repo.GetPermissionIDByName(ctx, []string{"articles.get", "typo.get"})
+	var notFound *repository.NotFoundError
+	if !errors.As(err, &notFound) || !reflect.DeepEqual(notFound.Names, []string{"typo.get"}) {
+		t.Errorf("expected the missing name typo.get, got %v", err)
+	}
+	if !errors.Is(err, repository.ErrPermissionNotFound) {
+		t.Errorf("expected %v, got %v", repository.ErrPermissionNotFound, err)
+	}
+	if ids != nil {
+		t.Errorf("expected no IDs, got %v", ids)
+	}
+
+	// none of the names exists, every name is missing without a second query
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?,?)")).
+		WithArgs("ghost", "phantom").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = repo.GetRoleIDByName(ctx, []string{"ghost", "phantom"})
+	if err == nil || err.Error() != "role not found: ghost, phantom" {
+		t.Errorf("expected role not found: ghost, phantom, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetIDByNameEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+	ctx := context.Background()
+
+	// an empty list is not found without querying the database, like the memory store
+	ids, err := repo.GetPermissionIDByName(ctx, nil)
+	var notFound *repository.NotFoundError
+	if !errors.As(err, &notFound) || !errors.Is(err, repository.ErrPermissionNotFound) {
+		t.Errorf("expected %v, got %v", repository.ErrPermissionNotFound, err)
+	}
+	if ids != nil {
+		t.Errorf("expected no IDs, got %v", ids)
+	}
+
+	_, err = repo.GetRoleIDByName(ctx, []string{})
+	if !errors.As(err, &notFound) || !errors.Is(err, repository.ErrRoleNotFound) {
+		t.Errorf("expected %v, got %v", repository.ErrRoleNotFound, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRolesByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+	ctx := context.Background()
+
+	// unknown names are ignored
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "editor")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM roles WHERE name IN (?,?) ORDER BY id")).
+		WithArgs("editor", "ghost").
+		WillReturnRows(rows)
+
+	roles, err := repo.GetRolesByName(ctx, []string{"editor", "ghost"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if len(roles) != 1 || roles[0].ID != 2 || roles[0].Name != "editor" {
+		t.Errorf("expected [{2 editor}], got %v", roles)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestGetRoleAccountByID(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -577,3 +727,892 @@ func TestGetAccountHasRolePermissions(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokePermissionFromRole(t *testing.T) {
+	query := "DELETE FROM role_has_permissions WHERE role_id = ? AND permission_id = ?"
+	roleID := uint(1)
+	permissions := []uint{1, 2}
+
+	t.Run("Successful revocation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectBegin()
+		for _, permID := range permissions {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(roleID, permID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		if err := repo.RevokePermissionFromRole(context.Background(), roleID, permissions); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Permission not assigned", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(roleID, permissions[0]).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(roleID, permissions[1]).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = repo.RevokePermissionFromRole(context.Background(), roleID, permissions)
+		if err != repository.ErrPermissionNotAssigned {
+			t.Errorf("expected ErrPermissionNotAssigned, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRevokeRoleFromUser(t *testing.T) {
+	query := "DELETE FROM user_has_roles WHERE user_id = ? AND role_id = ?"
+	userID := uint(1)
+	roleID := uint(2)
+
+	t.Run("Successful removal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(userID, roleID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.RevokeRoleFromUser(context.Background(), userID, roleID); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Role not assigned", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(userID, roleID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = repo.RevokeRoleFromUser(context.Background(), userID, roleID)
+		if err != repository.ErrUserRoleNotAssigned {
+			t.Errorf("expected ErrUserRoleNotAssigned, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestGivePermissionToUser(t *testing.T) {
+	query := "INSERT INTO user_has_permissions (user_id, permission_id) VALUES (?, ?)"
+	userID := uint(1)
+	permissions := []uint{1, 2}
+
+	t.Run("Successful assignment", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectBegin()
+		for _, permID := range permissions {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(userID, permID).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		mock.ExpectCommit()
+
+		if err := repo.GivePermissionToUser(context.Background(), userID, permissions); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Duplicate user permission error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(userID, permissions[0]).
+			WillReturnError(fmt.Errorf("Error 1062: Duplicate entry '1-1' for key 'PRIMARY'"))
+		mock.ExpectRollback()
+
+		err = repo.GivePermissionToUser(context.Background(), userID, permissions)
+		if err != repository.ErrDuplicateUserPermission {
+			t.Errorf("expected ErrDuplicateUserPermission, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRevokePermissionFromUser(t *testing.T) {
+	query := "DELETE FROM user_has_permissions WHERE user_id = ? AND permission_id = ?"
+	userID := uint(1)
+	permissions := []uint{1, 2}
+
+	t.Run("Successful revocation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectBegin()
+		for _, permID := range permissions {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(userID, permID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		if err := repo.RevokePermissionFromUser(context.Background(), userID, permissions); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Permission not assigned", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		repo := repository.NewSQL(db, tableuser)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(userID, permissions[0]).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = repo.RevokePermissionFromUser(context.Background(), userID, permissions)
+		if err != repository.ErrUserPermissionNotAssigned {
+			t.Errorf("expected ErrUserPermissionNotAssigned, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestDeleteRoleAndPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		call        func(repo repository.SQL) error
+		result      sql.Result
+		expectedErr error
+	}{
+		{
+			name:   "Delete existing role",
+			query:  "DELETE FROM roles WHERE name = ?",
+			call:   func(repo repository.SQL) error { return repo.DeleteRole(context.Background(), "editor") },
+			result: sqlmock.NewResult(0, 1),
+		},
+		{
+			name:        "Delete missing role",
+			query:       "DELETE FROM roles WHERE name = ?",
+			call:        func(repo repository.SQL) error { return repo.DeleteRole(context.Background(), "editor") },
+			result:      sqlmock.NewResult(0, 0),
+			expectedErr: repository.ErrRoleNotFound,
+		},
+		{
+			name:   "Delete existing permission",
+			query:  "DELETE FROM permissions WHERE name = ?",
+			call:   func(repo repository.SQL) error { return repo.DeletePermission(context.Background(), "editor") },
+			result: sqlmock.NewResult(0, 1),
+		},
+		{
+			name:        "Delete missing permission",
+			query:       "DELETE FROM permissions WHERE name = ?",
+			call:        func(repo repository.SQL) error { return repo.DeletePermission(context.Background(), "editor") },
+			result:      sqlmock.NewResult(0, 0),
+			expectedErr: repository.ErrPermissionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectExec(regexp.QuoteMeta(tt.query)).
+				WithArgs("editor").
+				WillReturnResult(tt.result)
+
+			err = tt.call(repository.NewSQL(db, tableuser))
+			if err != tt.expectedErr {
+				t.Errorf("expected error: %v, got: %v", tt.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRenameRoleAndPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		call        func(repo repository.SQL) error
+		result      sql.Result
+		resultErr   error
+		expectedErr error
+	}{
+		{
+			name:   "Rename existing role",
+			query:  "UPDATE roles SET name = ? WHERE name = ?",
+			call:   func(repo repository.SQL) error { return repo.RenameRole(context.Background(), "old", "new") },
+			result: sqlmock.NewResult(0, 1),
+		},
+		{
+			name:        "Rename missing role",
+			query:       "UPDATE roles SET name = ? WHERE name = ?",
+			call:        func(repo repository.SQL) error { return repo.RenameRole(context.Background(), "old", "new") },
+			result:      sqlmock.NewResult(0, 0),
+			expectedErr: repository.ErrRoleNotFound,
+		},
+		{
+			name:        "Rename role to existing name",
+			query:       "UPDATE roles SET name = ? WHERE name = ?",
+			call:        func(repo repository.SQL) error { return repo.RenameRole(context.Background(), "old", "new") },
+			resultErr:   fmt.Errorf("Error 1062: Duplicate entry 'new' for key 'name'"),
+			expectedErr: repository.ErrDuplicateRole,
+		},
+		{
+			name:   "Rename existing permission",
+			query:  "UPDATE permissions SET name = ? WHERE name = ?",
+			call:   func(repo repository.SQL) error { return repo.RenamePermission(context.Background(), "old", "new") },
+			result: sqlmock.NewResult(0, 1),
+		},
+		{
+			name:        "Rename missing permission",
+			query:       "UPDATE permissions SET name = ? WHERE name = ?",
+			call:        func(repo repository.SQL) error { return repo.RenamePermission(context.Background(), "old", "new") },
+			result:      sqlmock.NewResult(0, 0),
+			expectedErr: repository.ErrPermissionNotFound,
+		},
+		{
+			name:        "Rename permission to existing name",
+			query:       "UPDATE permissions SET name = ? WHERE name = ?",
+			call:        func(repo repository.SQL) error { return repo.RenamePermission(context.Background(), "old", "new") },
+			resultErr:   fmt.Errorf("Error 1062: Duplicate entry 'new' for key 'name'"),
+			expectedErr: repository.ErrDuplicatePermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			exec := mock.ExpectExec(regexp.QuoteMeta(tt.query)).WithArgs("new", "old")
+			if tt.resultErr != nil {
+				exec.WillReturnError(tt.resultErr)
+			} else {
+				exec.WillReturnResult(tt.result)
+			}
+
+			err = tt.call(repository.NewSQL(db, tableuser))
+			if err != tt.expectedErr {
+				t.Errorf("expected error: %v, got: %v", tt.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestMySQLDuplicateEntry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+	ctx := context.Background()
+
+	// duplicate detected with the number of the driver error
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO roles (name) VALUES (?)")).
+		WithArgs("admin").
+		WillReturnError(fmt.Errorf("insert: %w", &mysqlError{number: 1062, message: "Duplicate entry 'admin' for key 'name'"}))
+
+	if err := repo.CreateRole(ctx, "admin"); err != repository.ErrDuplicateRole {
+		t.Errorf("expected %v, got %v", repository.ErrDuplicateRole, err)
+	}
+
+	// other numbers are returned as is, whatever the message
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO roles (name) VALUES (?)")).
+		WithArgs("admin").
+		WillReturnError(&mysqlError{number: 1452, message: "Cannot add or update a child row: Duplicate entry"})
+
+	if err := repo.CreateRole(ctx, "admin"); err == nil || err == repository.ErrDuplicateRole {
+		t.Errorf("expected a foreign key error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// mysqlError mimics the message of *mysql.MySQLError, the error number is part of the message
+type mysqlError struct {
+	number  uint16
+	message string
+}
+
+func (e *mysqlError) Error() string { return fmt.Sprintf("Error %d (23000): %s", e.number, e.message) }
+
+// pgError mimics the errors of the PostgreSQL drivers exposing the SQLSTATE
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string    { return "pq: error " + e.code }
+func (e *pgError) SQLState() string { return e.code }
+
+func TestPostgresDialect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser, repository.WithDialect(repository.DialectPostgres))
+	ctx := context.Background()
+
+	// duplicate detected with the SQLSTATE of the driver error
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO roles (name) VALUES ($1)")).
+		WithArgs("admin").
+		WillReturnError(fmt.Errorf("insert: %w", &pgError{code: "23505"}))
+
+	if err := repo.CreateRole(ctx, "admin"); err != repository.ErrDuplicateRole {
+		t.Errorf("expected %v, got %v", repository.ErrDuplicateRole, err)
+	}
+
+	// other SQLSTATE are returned as is
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO roles (name) VALUES ($1)")).
+		WithArgs("admin").
+		WillReturnError(&pgError{code: "23503"})
+
+	if err := repo.CreateRole(ctx, "admin"); err == nil || err == repository.ErrDuplicateRole {
+		t.Errorf("expected a foreign key error, got %v", err)
+	}
+
+	// duplicate detected from the message when the driver has no SQLState
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_has_roles (user_id, role_id) VALUES ($1, $2)")).
+		WithArgs(1, 2).
+		WillReturnError(fmt.Errorf("ERROR: duplicate key value violates unique constraint \"user_has_roles_pkey\" (SQLSTATE 23505)"))
+
+	if err := repo.GiveRoleToUser(ctx, 1, 2); err != repository.ErrDuplicateUserRole {
+		t.Errorf("expected %v, got %v", repository.ErrDuplicateUserRole, err)
+	}
+
+	// duplicated parents are skipped with ON CONFLICT
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM role_has_roles WHERE role_id = $1")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO role_has_roles (role_id, parent_role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING")).
+		WithArgs(2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.SetRoleParents(ctx, 2, []uint{3}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser, repository.WithDialect(repository.DialectSQLite))
+	ctx := context.Background()
+
+	// duplicate detected from the UNIQUE constraint message
+	mock.ExpectExec(regexp.QuoteMeta(mockqueryInsertRole)).
+		WithArgs("admin").
+		WillReturnError(fmt.Errorf("UNIQUE constraint failed: roles.name"))
+
+	if err := repo.CreateRole(ctx, "admin"); err != repository.ErrDuplicateRole {
+		t.Errorf("expected %v, got %v", repository.ErrDuplicateRole, err)
+	}
+
+	// foreign keys are enabled on the connection before the cascading delete
+	mock.ExpectExec(regexp.QuoteMeta("PRAGMA foreign_keys = ON")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM roles WHERE name = ?")).
+		WithArgs("admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.DeleteRole(ctx, "admin"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// offset without limit
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT id, name FROM permissions ORDER BY id LIMIT -1 OFFSET ?") + "$").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	if _, err := repo.ListPermissions(ctx, repository.Pagination{Offset: 5}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestTablesMapping(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser, repository.WithTables(repository.Tables{
+		Prefix:      "acl_",
+		Account:     "members",
+		AccountID:   "member_id",
+		AccountName: "display_name",
+		UserID:      "member_id",
+		Roles:       "groups",
+	}))
+	ctx := context.Background()
+
+	// mapped names are quoted, the default names are not
+	mock.ExpectQuery(regexp.QuoteMeta("a.`display_name` AS fullName") +
+		`(.|\n)*` + regexp.QuoteMeta("FROM `acl_user_has_roles` ur") +
+		`(.|\n)*` + regexp.QuoteMeta("`members` a ON ur.`member_id` = a.`member_id`") +
+		`(.|\n)*` + regexp.QuoteMeta("JOIN `acl_groups` r ON ur.role_id = r.id") +
+		`(.|\n)*` + regexp.QuoteMeta("a.`member_id` = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).AddRow("john", "admin"))
+
+	if _, err := repo.GetAccountRolesByID(ctx, 1); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT `member_id` FROM `acl_user_has_roles` WHERE role_id = ? ORDER BY `member_id`") + "$").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"member_id"}).AddRow(1))
+
+	if _, err := repo.ListUsersWithRole(ctx, 2, repository.Pagination{}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		tableAccount  string
+		opts          []repository.Option
+		expectedError error
+	}{
+		{
+			name:         "Default names",
+			tableAccount: tableuser,
+		},
+		{
+			name:         "Schema qualified account table",
+			tableAccount: "app.users",
+			opts:         []repository.Option{repository.WithDialect(repository.DialectPostgres)},
+		},
+		{
+			name:          "Injected account table",
+			tableAccount:  "users a ON 1=1; DROP TABLE roles; --",
+			expectedError: repository.ErrInvalidIdentifier,
+		},
+		{
+			name:          "Invalid prefix",
+			tableAccount:  tableuser,
+			opts:          []repository.Option{repository.WithTables(repository.Tables{Prefix: "acl-"})},
+			expectedError: repository.ErrInvalidIdentifier,
+		},
+		{
+			name:          "Qualified column",
+			tableAccount:  tableuser,
+			opts:          []repository.Option{repository.WithTables(repository.Tables{AccountID: "a.id"})},
+			expectedError: repository.ErrInvalidIdentifier,
+		},
+		{
+			name:          "Unknown dialect",
+			tableAccount:  tableuser,
+			opts:          []repository.Option{repository.WithDialect("oracle")},
+			expectedError: repository.ErrUnsupportedDialect,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewSQL(nil, tt.tableAccount, tt.opts...)
+
+			err := repo.Validate()
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestQuotedIdentifiers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, "app.user", repository.WithDialect(repository.DialectPostgres))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`JOIN "app"."user" a ON ur.user_id = a.id`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}))
+
+	if _, err := repo.GetAccountRolesByID(context.Background(), 1); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListRoles(t *testing.T) {
+	tests := []struct {
+		name         string
+		dialect      repository.Dialect
+		page         repository.Pagination
+		expectedSQL  string
+		expectedArgs []driver.Value
+	}{
+		{
+			name:        "Without pagination",
+			expectedSQL: "SELECT id, name FROM roles ORDER BY id",
+		},
+		{
+			name:         "Postgres with limit and offset",
+			dialect:      repository.DialectPostgres,
+			page:         repository.Pagination{Limit: 10, Offset: 20},
+			expectedSQL:  "SELECT id, name FROM roles ORDER BY id LIMIT $1 OFFSET $2",
+			expectedArgs: []driver.Value{10, 20},
+		},
+		{
+			name:         "Postgres with offset only",
+			dialect:      repository.DialectPostgres,
+			page:         repository.Pagination{Offset: 5},
+			expectedSQL:  "SELECT id, name FROM roles ORDER BY id OFFSET $1",
+			expectedArgs: []driver.Value{5},
+		},
+		{
+			name:         "With limit and offset",
+			page:         repository.Pagination{Limit: 10, Offset: 20},
+			expectedSQL:  "SELECT id, name FROM roles ORDER BY id LIMIT ? OFFSET ?",
+			expectedArgs: []driver.Value{10, 20},
+		},
+		{
+			name:         "With offset only",
+			page:         repository.Pagination{Offset: 5},
+			expectedSQL:  "SELECT id, name FROM roles ORDER BY id LIMIT 18446744073709551615 OFFSET ?",
+			expectedArgs: []driver.Value{5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "admin").AddRow(2, "editor")
+			mock.ExpectQuery("^" + regexp.QuoteMeta(tt.expectedSQL) + "$").
+				WithArgs(tt.expectedArgs...).
+				WillReturnRows(rows)
+
+			repo := repository.NewSQL(db, tableuser, repository.WithDialect(tt.dialect))
+			roles, err := repo.ListRoles(context.Background(), tt.page)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			expected := []repository.Role{{ID: 1, Name: "admin"}, {ID: 2, Name: "editor"}}
+			if !reflect.DeepEqual(roles, expected) {
+				t.Errorf("expected: %v, got: %v", expected, roles)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		page     repository.Pagination
+		expected []int
+	}{
+		{page: repository.Pagination{}, expected: []int{1, 2, 3, 4, 5}},
+		{page: repository.Pagination{Limit: 2}, expected: []int{1, 2}},
+		{page: repository.Pagination{Limit: 2, Offset: 2}, expected: []int{3, 4}},
+		{page: repository.Pagination{Offset: 3}, expected: []int{4, 5}},
+		{page: repository.Pagination{Offset: 5}, expected: nil},
+	}
+
+	for _, test := range tests {
+		if output := repository.Paginate(items, test.page); !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("Paginate(%v) returned %v, expected %v", test.page, output, test.expected)
+		}
+	}
+}
+
+func TestListUsersWithRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+
+	rows := sqlmock.NewRows([]string{"user_id"}).AddRow(4).AddRow(42)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM user_has_roles WHERE role_id = ? ORDER BY user_id")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	userIDs, err := repo.ListUsersWithRole(context.Background(), 1, repository.Pagination{})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(userIDs, []uint{4, 42}) {
+		t.Errorf("expected: %v, got: %v", []uint{4, 42}, userIDs)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetAccountRolesByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+
+	rows := sqlmock.NewRows([]string{"fullName", "roleName"}).
+		AddRow("john", "viewer").
+		AddRow("john", "Superadmin")
+	mock.ExpectQuery(regexp.QuoteMeta("JOIN users a ON ur.user_id = a.id")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	roles, err := repo.GetAccountRolesByID(context.Background(), 1)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expected := []repository.AccountRole{
+		{FullName: "john", RoleName: "viewer"},
+		{FullName: "john", RoleName: "Superadmin"},
+	}
+	if !reflect.DeepEqual(roles, expected) {
+		t.Errorf("expected: %v, got: %v", expected, roles)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSetRoleParents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM role_has_roles WHERE role_id = ?")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO role_has_roles (role_id, parent_role_id) VALUES (?, ?)")).
+		WithArgs(2, 3).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO role_has_roles (role_id, parent_role_id) VALUES (?, ?)")).
+		WithArgs(2, 4).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	err = repo.SetRoleParents(context.Background(), 2, []uint{3, 4})
+	if err == nil || !strings.Contains(err.Error(), sql.ErrConnDone.Error()) {
+		t.Errorf("expected error containing '%s', got %v", sql.ErrConnDone, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetPermissionsByRoleIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+
+	// no role, no query
+	permissions, err := repo.GetPermissionsByRoleIDs(context.Background(), nil)
+	if err != nil || permissions != nil {
+		t.Errorf("expected no permissions and no error, got %v, %v", permissions, err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "articles.get")
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE rhp.role_id IN (?,?)")).
+		WithArgs(2, 4).
+		WillReturnRows(rows)
+
+	permissions, err = repo.GetPermissionsByRoleIDs(context.Background(), []uint{2, 4})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expected := []repository.Permission{{ID: 1, Name: "articles.get"}}
+	if !reflect.DeepEqual(permissions, expected) {
+		t.Errorf("expected: %v, got: %v", expected, permissions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDenyPermissionToUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSQL(db, tableuser)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_has_denied_permissions (user_id, permission_id) VALUES (?, ?)")).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.DenyPermissionToUser(context.Background(), 1, []uint{2}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "tickets.delete")
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE udp.user_id = ?")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	permissions, err := repo.GetUserDeniedPermissions(context.Background(), 1)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expected := []repository.Permission{{ID: 2, Name: "tickets.delete"}}
+	if !reflect.DeepEqual(permissions, expected) {
+		t.Errorf("expected: %v, got: %v", expected, permissions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,6 @@
+package repository
+
+type RoleHasRole struct {
+	RoleID       uint `json:"role_id"`
+	ParentRoleID uint `json:"parent_role_id"`
+}
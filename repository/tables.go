@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidIdentifier is returned by SQL.Validate when a table or column name of the mapping is not a plain identifier.
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// identifierPattern is a plain identifier: letters, digits and underscores, not starting with a digit.
+// 63 characters is the limit of PostgreSQL, MySQL allows 64.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// Tables maps the tables and columns used by SQL to the names of an existing schema.
+// Empty fields keep the default names of the migrations.
+// Names set here, and the tables renamed by Prefix, are quoted for the dialect in the queries.
+type Tables struct {
+	// Prefix is prepended to every table of confide_acl, the account table is not prefixed
+	Prefix string `json:"prefix"`
+
+	// Account is the table of the users, "users" by default or ConfigACL.TableAccount.
+	// it can be qualified with a schema, "app.users"
+	Account string `json:"account"`
+	// AccountID is the primary key of the account table, "id" by default
+	AccountID string `json:"account_id"`
+	// AccountName is the column holding the name of the user, "full_name" by default
+	AccountName string `json:"account_name"`
+	// UserID is the column referencing the account in the user_has_* tables, "user_id" by default
+	UserID string `json:"user_id"`
+
+	Roles                    string `json:"roles"`
+	Permissions              string `json:"permissions"`
+	RoleHasPermissions       string `json:"role_has_permissions"`
+	UserHasRoles             string `json:"user_has_roles"`
+	UserHasPermissions       string `json:"user_has_permissions"`
+	RoleHasRoles             string `json:"role_has_roles"`
+	RoleHasDeniedPermissions string `json:"role_has_denied_permissions"`
+	UserHasDeniedPermissions string `json:"user_has_denied_permissions"`
+}
+
+// tableName is a name of the mapping with the marker replaced by it in the queries.
+type tableName struct {
+	marker      string
+	value       *string
+	defaultName string
+	prefixed    bool // the name is a confide_acl table, Prefix applies
+	column      bool // the name is a column, it can't be qualified
+}
+
+// WithTables sets the table and column mapping of the database.
+// the Account table of the mapping replaces the tableAccountDefault of NewSQL when set.
+func WithTables(tables Tables) Option {
+	return func(s *SQL) {
+		s.tables = tables
+	}
+}
+
+// names lists the names of the mapping.
+func (t *Tables) names() []tableName {
+	return []tableName{
+		{marker: "{account}", value: &t.Account, defaultName: "users"},
+		{marker: "{account_id}", value: &t.AccountID, defaultName: "id", column: true},
+		{marker: "{account_name}", value: &t.AccountName, defaultName: "full_name", column: true},
+		{marker: "{user_id}", value: &t.UserID, defaultName: "user_id", column: true},
+		{marker: "{roles}", value: &t.Roles, defaultName: "roles", prefixed: true},
+		{marker: "{permissions}", value: &t.Permissions, defaultName: "permissions", prefixed: true},
+		{marker: "{role_has_permissions}", value: &t.RoleHasPermissions, defaultName: "role_has_permissions", prefixed: true},
+		{marker: "{user_has_roles}", value: &t.UserHasRoles, defaultName: "user_has_roles", prefixed: true},
+		{marker: "{user_has_permissions}", value: &t.UserHasPermissions, defaultName: "user_has_permissions", prefixed: true},
+		{marker: "{role_has_roles}", value: &t.RoleHasRoles, defaultName: "role_has_roles", prefixed: true},
+		{marker: "{role_has_denied_permissions}", value: &t.RoleHasDeniedPermissions, defaultName: "role_has_denied_permissions", prefixed: true},
+		{marker: "{user_has_denied_permissions}", value: &t.UserHasDeniedPermissions, defaultName: "user_has_denied_permissions", prefixed: true},
+	}
+}
+
+// withDefaults fills the empty names with the default names, the prefix is applied to the confide_acl tables.
+func (t Tables) withDefaults() Tables {
+	for _, name := range t.names() {
+		if *name.value == "" {
+			*name.value = name.defaultName
+		}
+		if name.prefixed {
+			*name.value = t.Prefix + *name.value
+		}
+	}
+	return t
+}
+
+// validate checks every name of a mapping filled by withDefaults.
+func (t Tables) validate() error {
+	for _, name := range t.names() {
+		parts := strings.Split(*name.value, ".")
+		if len(parts) > 2 || (name.column && len(parts) > 1) {
+			return fmt.Errorf("%w: %q for %s", ErrInvalidIdentifier, *name.value, strings.Trim(name.marker, "{}"))
+		}
+		for _, part := range parts {
+			if !identifierPattern.MatchString(part) {
+				return fmt.Errorf("%w: %q for %s", ErrInvalidIdentifier, *name.value, strings.Trim(name.marker, "{}"))
+			}
+		}
+	}
+	return nil
+}
+
+// replacer replaces the {name} markers of the queries with the mapped names of a mapping filled by withDefaults.
+// the default names are written as is, the other names are quoted for the dialect.
+func (t Tables) replacer(dialect Dialect) *strings.Replacer {
+	var pairs []string
+	for _, name := range t.names() {
+		value := *name.value
+		if value != name.defaultName {
+			value = dialect.quoteIdentifier(value)
+		}
+		pairs = append(pairs, name.marker, value)
+	}
+	return strings.NewReplacer(pairs...)
+}
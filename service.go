@@ -2,14 +2,19 @@ package confide_acl
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/cangkir13/confide_acl/repository"
 )
 
 type service struct {
-	repo repository.SQL
+	repo            repository.RepositoryService
+	superAdminRoles []string
+	paths           *PathNormalizer
+	verbs           verbGroups
 }
 
 // AddRole sets a new role in the system.
@@ -23,7 +28,7 @@ type service struct {
 func (s *service) AddRole(ctx context.Context, name string) error {
 	err := s.repo.CreateRole(ctx, name)
 	if err != nil {
-		return err
+		return newError(err, KindRole, name)
 	}
 	return nil
 }
@@ -49,37 +54,156 @@ func (s *service) AddRole(ctx context.Context, name string) error {
 func (s *service) AddPermission(ctx context.Context, name string) error {
 	err := s.repo.CreatePermission(ctx, name)
 	if err != nil {
-		return err
+		return newError(err, KindPermission, name)
 	}
 	return nil
 }
 
+// DeleteRole removes a role from the system together with its permission and user assignments.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The name of the role to be deleted.
+//
+// Returns:
+// - error: repository.ErrRoleNotFound if the role does not exist, an error if the deletion fails, otherwise nil.
+func (s *service) DeleteRole(ctx context.Context, name string) error {
+	err := s.repo.DeleteRole(ctx, name)
+	if err != nil {
+		return newError(err, KindRole, name)
+	}
+	return nil
+}
+
+// DeletePermission removes a permission from the system together with its role and user assignments.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The name of the permission to be deleted.
+//
+// Returns:
+// - error: repository.ErrPermissionNotFound if the permission does not exist, an error if the deletion fails, otherwise nil.
+func (s *service) DeletePermission(ctx context.Context, name string) error {
+	err := s.repo.DeletePermission(ctx, name)
+	if err != nil {
+		return newError(err, KindPermission, name)
+	}
+	return nil
+}
+
+// RenameRole changes the name of a role. Assignments keep pointing at the renamed role.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The current name of the role.
+// - newName: The new name of the role.
+//
+// Returns:
+// - error: repository.ErrRoleNotFound if the role does not exist, repository.ErrDuplicateRole if
+// newName is already taken, an error if the update fails, otherwise nil.
+func (s *service) RenameRole(ctx context.Context, name, newName string) error {
+	// renaming to the same name does not change any row, only check that the role exists
+	if name == newName {
+		_, err := s.repo.GetRoleIDByName(ctx, []string{name})
+		return newError(err, KindRole, name)
+	}
+
+	err := s.repo.RenameRole(ctx, name, newName)
+	if errors.Is(err, repository.ErrDuplicateRole) {
+		return newError(err, KindRole, newName)
+	}
+	if err != nil {
+		return newError(err, KindRole, name)
+	}
+	return nil
+}
+
+// RenamePermission changes the name of a permission. Assignments keep pointing at the renamed permission.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - name: The current name of the permission.
+// - newName: The new name of the permission.
+//
+// Returns:
+// - error: repository.ErrPermissionNotFound if the permission does not exist, repository.ErrDuplicatePermission
+// if newName is already taken, an error if the update fails, otherwise nil.
+func (s *service) RenamePermission(ctx context.Context, name, newName string) error {
+	// renaming to the same name does not change any row, only check that the permission exists
+	if name == newName {
+		_, err := s.repo.GetPermissionIDByName(ctx, []string{name})
+		return newError(err, KindPermission, name)
+	}
+
+	err := s.repo.RenamePermission(ctx, name, newName)
+	if errors.Is(err, repository.ErrDuplicatePermission) {
+		return newError(err, KindPermission, newName)
+	}
+	if err != nil {
+		return newError(err, KindPermission, name)
+	}
+	return nil
+}
+
+// AssignOption configures an assignment of AssignPermissionToRole.
+type AssignOption func(*assignOptions)
+
+type assignOptions struct {
+	skipMissing bool
+}
+
+// SkipMissingPermissions makes AssignPermissionToRole partial, the permissions that do not exist are skipped
+// and the other ones are assigned. ErrNotFound is still returned when none of the permissions exists.
+func SkipMissingPermissions() AssignOption {
+	return func(o *assignOptions) {
+		o.skipMissing = true
+	}
+}
+
 // AssignPermissionToRole assigns a list of permissions to a role in the system.
+// the assignment is all-or-nothing: nothing is assigned when one of the permissions does not exist,
+// unless SkipMissingPermissions is given.
 //
 // Parameters:
 // - ctx: The context.Context object for the request.
 // - role: The name of the role to which the permissions will be assigned.
 // - permissions: A slice of strings representing the names of the permissions to be assigned.
+// - opts: The options of the assignment, SkipMissingPermissions.
 //
 // Returns:
-// - error: An error if the assignment fails, otherwise nil.
-func (s *service) AssignPermissionToRole(ctx context.Context, role string, permissions []string) error {
+// - error: An error matching ErrNotFound with the missing names in Error.Name if the role or one of the
+// permissions does not exist, an error if the assignment fails, otherwise nil.
+func (s *service) AssignPermissionToRole(ctx context.Context, role string, permissions []string, opts ...AssignOption) error {
+	var options assignOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// get role id by string
 	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
 	if err != nil {
-		return err
+		return newError(err, KindRole, role)
 	}
 
 	// get permission id by string
 	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+
+	// partial assignment, look the existing permissions up again without the missing ones
+	var notFound *repository.NotFoundError
+	if options.skipMissing && errors.As(err, &notFound) {
+		if existing := withoutStrings(permissions, notFound.Names); len(existing) > 0 {
+			permissions = existing
+			permissionIDs, err = s.repo.GetPermissionIDByName(ctx, permissions)
+		}
+	}
 	if err != nil {
-		return err
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
 	}
 
 	// assign permission to role
 	err = s.repo.GivePermissionToRole(ctx, roleIDs[0], permissionIDs)
 	if err != nil {
-		return err
+		return newError(err, KindRolePermission, strings.Join(permissions, ", "))
 	}
 	return nil
 }
@@ -97,13 +221,453 @@ func (s *service) AssignUserToRole(ctx context.Context, userid uint, role string
 	// get role id by string
 	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
 	if err != nil {
-		return err
+		return newError(err, KindRole, role)
 	}
 
 	// assign permission to role
 	err = s.repo.GiveRoleToUser(ctx, userid, roleIDs[0])
 	if err != nil {
-		return err
+		return newError(err, KindUserRole, role)
+	}
+	return nil
+}
+
+// RevokePermissionFromRole revokes a list of permissions from a role in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - role: The name of the role from which the permissions will be revoked.
+// - permissions: A slice of strings representing the names of the permissions to be revoked.
+//
+// Returns:
+// - error: An error if the revocation fails, otherwise nil. repository.ErrPermissionNotAssigned is
+// returned when one of the permissions is not assigned to the role, in which case nothing is revoked.
+func (s *service) RevokePermissionFromRole(ctx context.Context, role string, permissions []string) error {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// revoke permission from role
+	err = s.repo.RevokePermissionFromRole(ctx, roleIDs[0], permissionIDs)
+	if err != nil {
+		return newError(err, KindRolePermission, strings.Join(permissions, ", "))
+	}
+	return nil
+}
+
+// RemoveUserFromRole removes a user from a role in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user to be removed from the role.
+// - role: The name of the role from which the user will be removed.
+//
+// Returns:
+// - error: An error if the removal fails, otherwise nil. repository.ErrUserRoleNotAssigned is
+// returned when the user does not hold the role.
+func (s *service) RemoveUserFromRole(ctx context.Context, userid uint, role string) error {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+
+	// remove role from user
+	err = s.repo.RevokeRoleFromUser(ctx, userid, roleIDs[0])
+	if err != nil {
+		return newError(err, KindUserRole, role)
+	}
+	return nil
+}
+
+// GivePermissionToUser assigns a list of permissions directly to a user in the system.
+// Direct permissions are checked by the "permission:" part of a policy in PolicyACL.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user to whom the permissions will be assigned.
+// - permissions: A slice of strings representing the names of the permissions to be assigned.
+//
+// Returns:
+// - error: An error if the assignment fails, otherwise nil.
+func (s *service) GivePermissionToUser(ctx context.Context, userid uint, permissions []string) error {
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// assign permission to user
+	err = s.repo.GivePermissionToUser(ctx, userid, permissionIDs)
+	if err != nil {
+		return newError(err, KindUserPermission, strings.Join(permissions, ", "))
+	}
+	return nil
+}
+
+// RevokePermissionFromUser revokes a list of directly assigned permissions from a user in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user from whom the permissions will be revoked.
+// - permissions: A slice of strings representing the names of the permissions to be revoked.
+//
+// Returns:
+// - error: An error if the revocation fails, otherwise nil. repository.ErrUserPermissionNotAssigned is
+// returned when one of the permissions is not assigned to the user, in which case nothing is revoked.
+func (s *service) RevokePermissionFromUser(ctx context.Context, userid uint, permissions []string) error {
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// revoke permission from user
+	err = s.repo.RevokePermissionFromUser(ctx, userid, permissionIDs)
+	if err != nil {
+		return newError(err, KindUserPermission, strings.Join(permissions, ", "))
+	}
+	return nil
+}
+
+// ListRoles returns the roles registered in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - page: The pagination applied to the result, a zero value returns every role.
+//
+// Returns:
+// - []repository.Role: The roles ordered by ID.
+// - error: An error if the query fails, otherwise nil.
+func (s *service) ListRoles(ctx context.Context, page repository.Pagination) ([]repository.Role, error) {
+	roles, err := s.repo.ListRoles(ctx, page)
+	if err != nil {
+		return nil, newError(err, KindRole, "")
+	}
+	return roles, nil
+}
+
+// ListPermissions returns the permissions registered in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - page: The pagination applied to the result, a zero value returns every permission.
+//
+// Returns:
+// - []repository.Permission: The permissions ordered by ID.
+// - error: An error if the query fails, otherwise nil.
+func (s *service) ListPermissions(ctx context.Context, page repository.Pagination) ([]repository.Permission, error) {
+	permissions, err := s.repo.ListPermissions(ctx, page)
+	if err != nil {
+		return nil, newError(err, KindPermission, "")
+	}
+	return permissions, nil
+}
+
+// GetRolePermissions returns the permissions assigned to a role.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - role: The name of the role.
+// - page: The pagination applied to the result, a zero value returns every permission.
+//
+// Returns:
+// - []repository.Permission: The permissions of the role ordered by ID.
+// - error: repository.ErrRoleNotFound if the role does not exist, an error if the query fails, otherwise nil.
+func (s *service) GetRolePermissions(ctx context.Context, role string, page repository.Pagination) ([]repository.Permission, error) {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return nil, newError(err, KindRole, role)
+	}
+
+	permissions, err := s.repo.GetRolePermissions(ctx, roleIDs[0], page)
+	if err != nil {
+		return nil, newError(err, KindRolePermission, role)
+	}
+	return permissions, nil
+}
+
+// GetUserRoles returns the roles held by a user.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user.
+// - page: The pagination applied to the result, a zero value returns every role.
+//
+// Returns:
+// - []repository.Role: The roles of the user ordered by ID.
+// - error: An error if the query fails, otherwise nil.
+func (s *service) GetUserRoles(ctx context.Context, userid uint, page repository.Pagination) ([]repository.Role, error) {
+	roles, err := s.repo.GetUserRoles(ctx, userid, page)
+	if err != nil {
+		return nil, newError(err, KindUserRole, "")
+	}
+	return roles, nil
+}
+
+// GetUserPermissions returns the effective permissions of a user, the direct permissions together with
+// the permissions of every role the user holds, including the roles inherited through SetRoleParents.
+// the permissions denied to the user or to one of the roles, wildcard denies included, are left out like in PolicyACL.
+// a deny covering a part of a permission only, "products.delete" for "products.*", keeps the permission.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user.
+// - page: The pagination applied to the result, a zero value returns every permission.
+//
+// Returns:
+// - []repository.Permission: The effective permissions of the user ordered by ID.
+// - error: An error if the query fails, otherwise nil.
+func (s *service) GetUserPermissions(ctx context.Context, userid uint, page repository.Pagination) ([]repository.Permission, error) {
+	// roles held by the user, directly or inherited
+	roleIDs, _, err := s.userRoleHierarchy(ctx, userid)
+	if err != nil {
+		return nil, newError(err, KindUserRole, "")
+	}
+
+	rolePermissions, err := s.repo.GetPermissionsByRoleIDs(ctx, roleIDs)
+	if err != nil {
+		return nil, newError(err, KindRolePermission, "")
+	}
+
+	directPermissions, err := s.repo.GetUserDirectPermissions(ctx, userid, repository.Pagination{})
+	if err != nil {
+		return nil, newError(err, KindUserPermission, "")
+	}
+
+	userDenied, err := s.repo.GetUserDeniedPermissions(ctx, userid)
+	if err != nil {
+		return nil, newError(err, KindDeniedPermission, "")
+	}
+
+	roleDenied, err := s.repo.GetDeniedPermissionsByRoleIDs(ctx, roleIDs)
+	if err != nil {
+		return nil, newError(err, KindDeniedPermission, "")
+	}
+	denied := append(userDenied, roleDenied...)
+
+	// merge both lists without duplicates and denied permissions, ordered by ID like the other list queries
+	seen := make(map[uint]bool)
+	var permissions []repository.Permission
+	for _, permission := range append(rolePermissions, directPermissions...) {
+		if seen[permission.ID] {
+			continue
+		}
+		seen[permission.ID] = true
+
+		if !coveredPermission(denied, permission.Name, s.verbs) {
+			permissions = append(permissions, permission)
+		}
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i].ID < permissions[j].ID })
+
+	return repository.Paginate(permissions, page), nil
+}
+
+// GetUserDirectPermissions returns the permissions assigned directly to a user with GivePermissionToUser.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user.
+// - page: The pagination applied to the result, a zero value returns every permission.
+//
+// Returns:
+// - []repository.Permission: The direct permissions of the user ordered by ID.
+// - error: An error if the query fails, otherwise nil.
+func (s *service) GetUserDirectPermissions(ctx context.Context, userid uint, page repository.Pagination) ([]repository.Permission, error) {
+	permissions, err := s.repo.GetUserDirectPermissions(ctx, userid, page)
+	if err != nil {
+		return nil, newError(err, KindUserPermission, "")
+	}
+	return permissions, nil
+}
+
+// ListUsersWithRole returns the IDs of the users holding a role.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - role: The name of the role.
+// - page: The pagination applied to the result, a zero value returns every user.
+//
+// Returns:
+// - []uint: The user IDs ordered ascending.
+// - error: repository.ErrRoleNotFound if the role does not exist, an error if the query fails, otherwise nil.
+func (s *service) ListUsersWithRole(ctx context.Context, role string, page repository.Pagination) ([]uint, error) {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return nil, newError(err, KindRole, role)
+	}
+
+	users, err := s.repo.ListUsersWithRole(ctx, roleIDs[0], page)
+	if err != nil {
+		return nil, newError(err, KindUserRole, role)
+	}
+	return users, nil
+}
+
+// SetRoleParents replaces the parent roles of a role. The role inherits the permissions of its parents
+// transitively, and a user holding the role satisfies "role:" clauses naming one of its ancestors.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - role: The name of the role.
+// - parents: A slice of strings representing the names of the parent roles, an empty slice removes every parent.
+//
+// Returns:
+// - error: repository.ErrRoleNotFound if the role or one of the parents does not exist, an *Error matching
+// ErrInvalidArgument and ErrRoleHierarchyCycle if the role would inherit from itself, an error if the update fails, otherwise nil.
+func (s *service) SetRoleParents(ctx context.Context, role string, parents []string) error {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+
+	// get parent role ids by string, every parent must exist
+	var parentIDs []uint
+	if len(parents) > 0 {
+		parentIDs, err = s.repo.GetRoleIDByName(ctx, parents)
+		if err != nil {
+			return newError(err, KindRole, strings.Join(parents, ", "))
+		}
+	}
+
+	// check the new parents against the stored hierarchy
+	hierarchy, err := s.repo.GetRoleHierarchy(ctx)
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+
+	index := roleParents(hierarchy)
+	if createsRoleCycle(roleIDs[0], parentIDs, index) {
+		return newError(ErrRoleHierarchyCycle, KindRole, role)
+	}
+
+	err = s.repo.SetRoleParents(ctx, roleIDs[0], parentIDs)
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+	return nil
+}
+
+// DenyPermissionToRole denies a list of permissions to a role in the system. A denied permission overrides
+// every grant in PolicyACL for the users holding the role or one of its child roles.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - role: The name of the role to which the permissions will be denied.
+// - permissions: A slice of strings representing the names of the permissions to be denied, wildcards included.
+//
+// Returns:
+// - error: An error if the deny fails, otherwise nil.
+func (s *service) DenyPermissionToRole(ctx context.Context, role string, permissions []string) error {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// deny permission to role
+	err = s.repo.DenyPermissionToRole(ctx, roleIDs[0], permissionIDs)
+	if err != nil {
+		return newError(err, KindDeniedPermission, strings.Join(permissions, ", "))
+	}
+	return nil
+}
+
+// RemoveDenyFromRole removes a list of denied permissions from a role in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - role: The name of the role.
+// - permissions: A slice of strings representing the names of the denied permissions to be removed.
+//
+// Returns:
+// - error: An error if the removal fails, otherwise nil. repository.ErrPermissionNotDenied is
+// returned when one of the permissions is not denied to the role, in which case nothing is removed.
+func (s *service) RemoveDenyFromRole(ctx context.Context, role string, permissions []string) error {
+	// get role id by string
+	roleIDs, err := s.repo.GetRoleIDByName(ctx, []string{role})
+	if err != nil {
+		return newError(err, KindRole, role)
+	}
+
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// remove denied permission from role
+	err = s.repo.RemoveDeniedPermissionFromRole(ctx, roleIDs[0], permissionIDs)
+	if err != nil {
+		return newError(err, KindDeniedPermission, strings.Join(permissions, ", "))
+	}
+	return nil
+}
+
+// DenyPermissionToUser denies a list of permissions to a user in the system.
+// A denied permission overrides every grant of the user in PolicyACL.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user to whom the permissions will be denied.
+// - permissions: A slice of strings representing the names of the permissions to be denied, wildcards included.
+//
+// Returns:
+// - error: An error if the deny fails, otherwise nil.
+func (s *service) DenyPermissionToUser(ctx context.Context, userid uint, permissions []string) error {
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// deny permission to user
+	err = s.repo.DenyPermissionToUser(ctx, userid, permissionIDs)
+	if err != nil {
+		return newError(err, KindDeniedPermission, strings.Join(permissions, ", "))
+	}
+	return nil
+}
+
+// RemoveDenyFromUser removes a list of denied permissions from a user in the system.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userid: The ID of the user.
+// - permissions: A slice of strings representing the names of the denied permissions to be removed.
+//
+// Returns:
+// - error: An error if the removal fails, otherwise nil. repository.ErrPermissionNotDenied is
+// returned when one of the permissions is not denied to the user, in which case nothing is removed.
+func (s *service) RemoveDenyFromUser(ctx context.Context, userid uint, permissions []string) error {
+	// get permission id by string
+	permissionIDs, err := s.repo.GetPermissionIDByName(ctx, permissions)
+	if err != nil {
+		return newError(err, KindPermission, strings.Join(permissions, ", "))
+	}
+
+	// remove denied permission from user
+	err = s.repo.RemoveDeniedPermissionFromUser(ctx, userid, permissionIDs)
+	if err != nil {
+		return newError(err, KindDeniedPermission, strings.Join(permissions, ", "))
 	}
 	return nil
 }
@@ -123,111 +687,410 @@ func (s *service) AssignUserToRole(ctx context.Context, userid uint, role string
 //
 // note: this function is used inside the middleware
 // example rolepermission: "role:admin" or "permission:product.crete" or "role:admin|permission:product.create" or "role:admin,user" or you can use multiple roles and permissions
+// rolePermission is a boolean expression, clauses can be combined with AND, OR, NOT and parentheses,
+// example: "role:editor AND permission:articles.publish", "(role:admin OR role:editor) AND NOT role:suspended".
+// a "role:" clause is satisfied when the user holds one of the roles, directly or through a child role,
+// and the role or one of its ancestors grants module.method,
+// a "permission:" clause is satisfied when one of the permissions is assigned to the user and grants module.method.
+// under NOT a clause is the membership of the role or of the permission: "NOT role:suspended" refuses every user
+// holding suspended, whatever suspended grants, and "NOT permission:articles.delete" every user holding articles.delete,
+// directly or through one of the user's roles. the policy is a boolean expression: "NOT role:suspended" alone allows
+// every user not holding suspended and "role:editor OR NOT role:suspended" the editors and every user not holding
+// suspended, the deny rules are checked in both cases. "NOT NOT role:editor" is "role:editor".
+// NOT over a group follows De Morgan's laws: "NOT (NOT role:a AND NOT role:b)" is "role:a OR role:b".
+// stored permissions may use wildcards: "products.*" grants every method of products, "*.get" grants get on
+// every module and "*" grants everything.
+// a permission denied to the user or to one of the user's roles overrides every grant, only the super-admin bypass
+// is checked before the deny rules
 // example module and method: "GET /api/v1/products"
 // example: service.PolicyACL(ctx, 1, "role:admin|permission:product.create", "products", "GET")
-// note: you can insert product path as module and then http method GET as method
+// note: you can insert product path as module and then http method GET as method, the path is converted to the
+// module of the permission names by ConfigACL.PathNormalizer, "/products/17" to "products" by default. set it to
+// strip a prefix or a version, "/api/v1/products/17" to "products"
 func (s *service) PolicyACL(ctx context.Context, userID int, rolePermission, module, method string) (bool, error) {
-	// Parse the role or permission string
-	parsedRolePermission, err := parseRolePermission(rolePermission)
+	decision, err := s.Explain(ctx, userID, rolePermission, module, method)
 	if err != nil {
 		return false, err
 	}
 
-	// Verify the user's privilege
-	verified, err := s.verifyPrivilege(ctx, userID, parsedRolePermission, module, method)
+	return decision.Allowed, nil
+}
+
+// Explain evaluates a policy like PolicyACL and returns a Decision describing how the result was reached:
+// the super-admin bypass, the clause, role and permission that granted access, the deny rule that
+// overrode it, or why the policy was not satisfied.
+//
+// Parameters:
+// - ctx: The context.Context object for the request.
+// - userID: The ID of the user.
+// - rolePermission: The policy expression, see PolicyACL.
+// - module: The name of the module.
+// - method: The name of the HTTP method.
+//
+// Returns:
+// - Decision: The decision, Reason is ReasonInvalidPolicy when the policy could not be parsed.
+// - error: An *Error matching ErrInvalidPolicy if the policy could not be parsed, ErrStorage if the
+// user's privilege could not be verified, otherwise nil.
+func (s *service) Explain(ctx context.Context, userID int, rolePermission, module, method string) (Decision, error) {
+	// Parse the role or permission expression
+	policy, err := parsePolicy(rolePermission)
 	if err != nil {
-		return false, err
+		decision := Decision{Reason: ReasonInvalidPolicy, Module: s.normalizeModule(module), Method: strings.ToLower(method)}
+		return decision, &Error{Code: ErrInvalidPolicy, Kind: KindPolicy, Name: rolePermission, Err: err}
 	}
 
-	return verified, nil
+	// Verify the user's privilege
+	decision, err := s.verifyPrivilege(ctx, userID, policy, module, method)
+	return decision, newError(err, KindPolicy, rolePermission)
+}
+
+// normalizeModule lower cases the module, a path is converted by the PathNormalizer of the configuration first.
+func (s *service) normalizeModule(module string) string {
+	return strings.ToLower(s.paths.Normalize(module))
 }
 
 // VerifyPrivilege checks if a user has the privilege to access a specific module and method.
-func (s *service) verifyPrivilege(ctx context.Context, userID int, rolePermission RolePermission, module, method string) (bool, error) {
-	module = strings.ToLower(module)
+func (s *service) verifyPrivilege(ctx context.Context, userID int, policy policyNode, module, method string) (Decision, error) {
+	module = s.normalizeModule(module)
 	method = strings.ToLower(method)
 
-	isSuperAdmin, err := s.isSuperAdmin(ctx, uint(userID))
+	decision := Decision{
+		Reason: ReasonNotGranted,
+		Policy: policy.String(),
+		Module: module,
+		Method: method,
+	}
+
+	superAdminRole, err := s.isSuperAdmin(ctx, uint(userID))
 	if err != nil {
-		return false, err
+		return decision, err
 	}
 
-	if isSuperAdmin {
-		return true, nil
+	if superAdminRole != "" {
+		decision.Allowed = true
+		decision.Reason = ReasonSuperAdmin
+		decision.SuperAdminRole = superAdminRole
+		return decision, nil
 	}
 
-	roleAccess, err := s.CheckRoleAccess(ctx, uint(userID), rolePermission.Roles, module, method)
+	// the roles of the user are shared by every clause of the policy
+	held := &userRoles{userID: uint(userID)}
+
+	allowed, grant, err := s.evaluatePolicy(ctx, held, policy, module, method, false, &decision.Clauses)
+	if err != nil || !allowed {
+		return decision, err
+	}
+
+	// a policy only satisfied through NOT, like "NOT role:suspended", has no granting clause to report
+	decision.MatchedRule = grant.Clause
+	decision.Role = grant.Role
+	decision.Permission = grant.Permission
+
+	// a denied permission overrides the grants of the policy
+	denied, err := s.checkDeniedAccess(ctx, held, module, method)
 	if err != nil {
-		return false, err
+		return decision, err
+	}
+
+	if denied.permission != "" {
+		decision.Reason = ReasonDenied
+		decision.DeniedPermission = denied.permission
+		decision.DeniedBy = denied.source
+		return decision, nil
+	}
+
+	decision.Allowed = true
+	decision.Reason = ReasonGranted
+	return decision, nil
+}
+
+// evaluatePolicy walks the policy AST for a user, the operands of AND and OR are short-circuited.
+// every evaluated clause is appended to clauses. the returned ClauseResult is the clause that
+// granted access to the node, it is empty when the node is only satisfied through NOT.
+// negated is true under an odd number of NOT, the node is then evaluated as its negation: AND and OR
+// are swapped like in De Morgan's laws and the clauses are evaluated as role or permission membership,
+// so "NOT (NOT role:a AND NOT role:b)" is evaluated as "role:a OR role:b".
+func (s *service) evaluatePolicy(ctx context.Context, held *userRoles, node policyNode, module, method string, negated bool, clauses *[]ClauseResult) (bool, ClauseResult, error) {
+	switch n := node.(type) {
+	case policyTerm:
+		result, err := s.evaluateClause(ctx, held, n, module, method, negated)
+		if err != nil {
+			return false, ClauseResult{}, err
+		}
+
+		*clauses = append(*clauses, result)
+		if negated {
+			// a negated clause only excludes, it grants nothing
+			return !result.Satisfied, ClauseResult{}, nil
+		}
+		if !result.Satisfied {
+			return false, ClauseResult{}, nil
+		}
+		return true, result, nil
+	case policyNot:
+		// the grant of an operand under an even number of NOT is kept
+		return s.evaluatePolicy(ctx, held, n.operand, module, method, !negated, clauses)
+	case policyAnd:
+		if negated {
+			return s.evaluateAny(ctx, held, n.operands, module, method, negated, clauses)
+		}
+		return s.evaluateAll(ctx, held, n.operands, module, method, negated, clauses)
+	case policyOr:
+		if negated {
+			return s.evaluateAll(ctx, held, n.operands, module, method, negated, clauses)
+		}
+		return s.evaluateAny(ctx, held, n.operands, module, method, negated, clauses)
+	}
+
+	return false, ClauseResult{}, fmt.Errorf("unknown policy node %T", node)
+}
+
+// evaluateAll is satisfied when every operand is satisfied, the first clause granting access is returned.
+func (s *service) evaluateAll(ctx context.Context, held *userRoles, operands []policyNode, module, method string, negated bool, clauses *[]ClauseResult) (bool, ClauseResult, error) {
+	var grant ClauseResult
+	for _, operand := range operands {
+		allowed, operandGrant, err := s.evaluatePolicy(ctx, held, operand, module, method, negated, clauses)
+		if err != nil || !allowed {
+			return false, ClauseResult{}, err
+		}
+		if grant.Clause == "" {
+			grant = operandGrant
+		}
+	}
+	return true, grant, nil
+}
+
+// evaluateAny is satisfied when one operand is satisfied. an operand only satisfied through NOT
+// does not stop the evaluation, a later one may grant access.
+func (s *service) evaluateAny(ctx context.Context, held *userRoles, operands []policyNode, module, method string, negated bool, clauses *[]ClauseResult) (bool, ClauseResult, error) {
+	satisfied := false
+	for _, operand := range operands {
+		allowed, grant, err := s.evaluatePolicy(ctx, held, operand, module, method, negated, clauses)
+		if err != nil {
+			return false, ClauseResult{}, err
+		}
+		if allowed && grant.Clause != "" {
+			return true, grant, nil
+		}
+		satisfied = satisfied || allowed
 	}
+	return satisfied, ClauseResult{}, nil
+}
+
+// evaluateClause checks a single "role:" or "permission:" clause of the policy.
+// a negated clause is satisfied when the user holds one of the roles or one of the permissions,
+// whatever they grant.
+func (s *service) evaluateClause(ctx context.Context, held *userRoles, term policyTerm, module, method string, negated bool) (ClauseResult, error) {
+	result := ClauseResult{Clause: term.raw}
+
+	if negated {
+		heldRoles, _, err := s.heldPolicyRoles(ctx, held, term.rolePermission.Roles)
+		if err != nil {
+			return result, err
+		}
+		if len(heldRoles) > 0 {
+			result.Satisfied = true
+			result.Role = heldRoles[0].Name
+			return result, nil
+		}
 
-	if roleAccess {
-		return true, nil
+		permission, err := s.heldPolicyPermission(ctx, held, term.rolePermission.Permissions)
+		if err != nil {
+			return result, err
+		}
+		result.Satisfied = permission != ""
+		result.Permission = permission
+		return result, nil
 	}
 
-	permissionAccess, err := s.checkPermissionAccess(ctx, uint(userID), rolePermission.Permissions, module, method)
+	role, permission, err := s.CheckRoleAccess(ctx, held, term.rolePermission.Roles, module, method)
 	if err != nil {
-		return false, err
+		return result, err
+	}
+
+	if permission == "" {
+		permission, err = s.checkPermissionAccess(ctx, held.userID, term.rolePermission.Permissions, module, method)
+		if err != nil {
+			return result, err
+		}
 	}
 
-	return permissionAccess, nil
+	result.Satisfied = permission != ""
+	result.Role = role
+	result.Permission = permission
+	return result, nil
 }
 
 // CheckRoleAccess checks if a user has access to a specific role and module method.
-func (s *service) CheckRoleAccess(ctx context.Context, userID uint, roles []string, module, method string) (bool, error) {
+// it returns the role of the list held by the user and the stored permission granting module.method,
+// both empty when access is not granted.
+func (s *service) CheckRoleAccess(ctx context.Context, held *userRoles, roles []string, module, method string) (string, string, error) {
+	heldRoles, parents, err := s.heldPolicyRoles(ctx, held, roles)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, role := range heldRoles {
+		// Get the permissions of the role and of the roles it inherits from
+		rolePermissions, err := s.repo.GetPermissionsByRoleIDs(ctx, inheritedRoles([]uint{role.ID}, parents))
+		if err != nil {
+			return "", "", err
+		}
+
+		// Check if one of the role permissions grants module.method, wildcard and verb group permissions included
+		if permission, granted := bestPermissionMatch(rolePermissions, module, method, s.verbs); granted {
+			return role.Name, permission.Name, nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// heldPolicyRoles returns the roles of the list held by the user, directly or through a child role,
+// together with the parents index of the role hierarchy.
+func (s *service) heldPolicyRoles(ctx context.Context, held *userRoles, roles []string) ([]repository.Role, map[uint][]uint, error) {
 	if len(roles) == 0 {
-		return false, nil
+		return nil, nil, nil
+	}
+
+	// Get the roles held by the user, directly or inherited from a parent role
+	heldRoleIDs, parents, err := s.loadUserRoles(ctx, held)
+	if err != nil || len(heldRoleIDs) == 0 {
+		return nil, nil, err
 	}
 
-	// Get the role permissions associated with the user's roles
-	rolePermissions, err := s.repo.GetAccountHasRolePermissions(ctx, userID, roles)
+	// Get the roles of the policy, unknown roles can't be held by the user
+	policyRoles, err := s.repo.GetRolesByName(ctx, roles)
 	if err != nil {
-		return false, err
+		return nil, nil, err
+	}
+
+	holds := make(map[uint]bool, len(heldRoleIDs))
+	for _, roleID := range heldRoleIDs {
+		holds[roleID] = true
 	}
 
-	// Construct the permission name from module and method
-	permissionName := module + "." + method
+	var heldRoles []repository.Role
+	for _, role := range policyRoles {
+		if holds[role.ID] {
+			heldRoles = append(heldRoles, role)
+		}
+	}
+	return heldRoles, parents, nil
+}
+
+// heldPolicyPermission returns the stored permission held by the user, directly or through a role the user
+// holds, covering one of the permission names of the list, "articles.*" covers "articles.delete".
+// it returns an empty string when the user holds none of them.
+func (s *service) heldPolicyPermission(ctx context.Context, held *userRoles, permissions []string) (string, error) {
+	if len(permissions) == 0 {
+		return "", nil
+	}
 
-	// Check if the user has the required permission
-	for _, permission := range rolePermissions.Permission {
-		if permission.Name == permissionName {
-			return true, nil
+	roleIDs, _, err := s.loadUserRoles(ctx, held)
+	if err != nil {
+		return "", err
+	}
+
+	var heldPermissions []repository.Permission
+	if len(roleIDs) > 0 {
+		heldPermissions, err = s.repo.GetPermissionsByRoleIDs(ctx, roleIDs)
+		if err != nil {
+			return "", err
 		}
 	}
 
-	return false, nil
+	directPermissions, err := s.repo.GetUserDirectPermissions(ctx, held.userID, repository.Pagination{})
+	if err != nil {
+		return "", err
+	}
+	heldPermissions = append(heldPermissions, directPermissions...)
+
+	for _, name := range permissions {
+		module, method := splitPermission(name)
+		if permission, ok := bestPermissionMatch(heldPermissions, module, method, s.verbs); ok {
+			return permission.Name, nil
+		}
+	}
+	return "", nil
 }
 
 // checkPermissionAccess checks if a user has access to a specific permission for a given module and method.
-func (s *service) checkPermissionAccess(ctx context.Context, userID uint, permissions []string, module, method string) (bool, error) {
+// it returns the stored permission granting module.method, empty when access is not granted.
+func (s *service) checkPermissionAccess(ctx context.Context, userID uint, permissions []string, module, method string) (string, error) {
 	if len(permissions) == 0 {
-		return false, nil
+		return "", nil
+	}
+
+	// the permissions of the list not granting module.method can't satisfy the clause
+	candidates := permissionCandidates(permissions, module, method, s.verbs)
+	if len(candidates) == 0 {
+		return "", nil
 	}
 
-	// Retrieve the permissions associated with the user's roles and permission list
-	accountPermissions, err := s.repo.GetAccountHasPermission(ctx, userID, permissions)
+	// Retrieve the permissions of the user matching the permission list or a wildcard covering it
+	accountPermissions, err := s.repo.GetAccountHasPermission(ctx, userID, candidates)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	// Construct the permission name from module and method
-	permissionName := module + "." + method
+	// Check if one of the user permissions grants module.method, wildcard and verb group permissions included
+	permission, _ := bestPermissionMatch(accountPermissions, module, method, s.verbs)
 
-	// Check if the user has the required permission
-	for _, accountPermission := range accountPermissions {
-		if accountPermission.Name == permissionName {
-			return true, nil
-		}
+	return permission.Name, nil
+}
+
+// checkDeniedAccess returns the most specific permission denied to the user, or to one of the roles
+// the user holds directly or inherited, matching module.method. The zero value is returned when nothing is denied.
+// the role closure of held is reused when the policy already loaded it.
+func (s *service) checkDeniedAccess(ctx context.Context, held *userRoles, module, method string) (deniedPermission, error) {
+	userDenied, err := s.repo.GetUserDeniedPermissions(ctx, held.userID)
+	if err != nil {
+		return deniedPermission{}, err
+	}
+
+	// a deny on the user is reported before a deny on a role
+	if permission, ok := bestPermissionMatch(userDenied, module, method, s.verbs); ok {
+		return deniedPermission{permission: permission.Name, source: DeniedByUser}, nil
+	}
+
+	roleIDs, _, err := s.loadUserRoles(ctx, held)
+	if err != nil {
+		return deniedPermission{}, err
+	}
+
+	roleDenied, err := s.repo.GetDeniedPermissionsByRoleIDs(ctx, roleIDs)
+	if err != nil {
+		return deniedPermission{}, err
 	}
 
-	return false, nil
+	if permission, ok := bestPermissionMatch(roleDenied, module, method, s.verbs); ok {
+		return deniedPermission{permission: permission.Name, source: DeniedByRole}, nil
+	}
+
+	return deniedPermission{}, nil
 }
 
-// Helper function to check if user is Superadmin
-func (s *service) isSuperAdmin(ctx context.Context, userid uint) (bool, error) {
-	account, err := s.repo.GetAccountRoleByID(ctx, userid)
-	if err != nil && err != sql.ErrNoRows {
-		return false, err
+// Helper function to check if user is Superadmin, returns the bypass role that matched
+// or an empty string when the user does not hold one of the configured super-admin roles.
+// when the user holds several bypass roles the first one in the configured order is returned
+func (s *service) isSuperAdmin(ctx context.Context, userid uint) (string, error) {
+	if len(s.superAdminRoles) == 0 {
+		return "", nil
+	}
+
+	// every role of the user is considered, the bypass does not depend on row order
+	accountRoles, err := s.repo.GetAccountRolesByID(ctx, userid)
+	if err != nil {
+		return "", err
+	}
+
+	for _, role := range s.superAdminRoles {
+		for _, account := range accountRoles {
+			if account.RoleName == role {
+				return role, nil
+			}
+		}
 	}
-	return account.RoleName == "Superadmin" || account.RoleName == "Admin", nil
+	return "", nil
 }
@@ -2,11 +2,15 @@ package confide_acl_test
 
 import (
 	"context"
+	"database/sql/driver"
+	"fmt"
 	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cangkir13/confide_acl"
+	"github.com/cangkir13/confide_acl/repository"
+	"github.com/cangkir13/confide_acl/repository/memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +24,8 @@ func TestCreateRole(t *testing.T) {
 		Database:     db,
 		TableAccount: "test",
 	}
-	service := confide_acl.NewService(conf)
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
 
 	// Periksa format dan ekspektasi query
 	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO roles (name) VALUES (?)")).
@@ -42,7 +47,8 @@ func TestCreatePermission(t *testing.T) {
 		Database:     db,
 		TableAccount: "test",
 	}
-	service := confide_acl.NewService(conf)
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
 
 	// Periksa format dan ekspektasi query
 	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO permissions (name) VALUES (?)")).
@@ -64,7 +70,8 @@ func TestAssignPermissionToRole(t *testing.T) {
 		Database:     db,
 		TableAccount: "test",
 	}
-	service := confide_acl.NewService(conf)
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
 
 	// Define test values
 	roleName := "admin"
@@ -125,7 +132,8 @@ func TestAssignUserToRole(t *testing.T) {
 		Database:     db,
 		TableAccount: "users",
 	}
-	svc := confide_acl.NewService(conf)
+	svc, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
 
 	tests := []struct {
 		name          string
@@ -209,3 +217,1335 @@ func TestAssignUserToRole(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokePermissionFromRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "test",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	roleName := "admin"
+	permissions := []string{"read", "write"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?)")).
+		WithArgs(roleName).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM permissions WHERE name IN (?,?)")).
+		WithArgs(permissions[0], permissions[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM role_has_permissions WHERE role_id = ? AND permission_id = ?")).
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM role_has_permissions WHERE role_id = ? AND permission_id = ?")).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = service.RevokePermissionFromRole(context.Background(), roleName, permissions)
+	require.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestRemoveUserFromRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	svc, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		mockFunc      func()
+		expectedError error
+	}{
+		{
+			name: "Successful removal",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?)")).
+					WithArgs("Admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM user_has_roles WHERE user_id = ? AND role_id = ?")).
+					WithArgs(123, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "User does not hold role",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?)")).
+					WithArgs("Admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM user_has_roles WHERE user_id = ? AND role_id = ?")).
+					WithArgs(123, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: repository.ErrUserRoleNotAssigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			err := svc.RemoveUserFromRole(context.Background(), 123, "Admin")
+			assert.ErrorIs(t, err, tt.expectedError)
+
+			err = mock.ExpectationsWereMet()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGivePermissionToUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	permissions := []string{"products.get", "products.post"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM permissions WHERE name IN (?,?)")).
+		WithArgs(permissions[0], permissions[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_has_permissions (user_id, permission_id) VALUES (?, ?)")).
+		WithArgs(42, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_has_permissions (user_id, permission_id) VALUES (?, ?)")).
+		WithArgs(42, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = service.GivePermissionToUser(context.Background(), 42, permissions)
+	require.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestRevokePermissionFromUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM permissions WHERE name IN (?)")).
+		WithArgs("products.get").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM user_has_permissions WHERE user_id = ? AND permission_id = ?")).
+		WithArgs(42, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = service.RevokePermissionFromUser(context.Background(), 42, []string{"products.get"})
+	assert.ErrorIs(t, err, repository.ErrUserPermissionNotAssigned)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestDeleteRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM roles WHERE name = ?")).
+		WithArgs("editor").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, service.DeleteRole(context.Background(), "editor"))
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM roles WHERE name = ?")).
+		WithArgs("ghost").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, service.DeleteRole(context.Background(), "ghost"), repository.ErrRoleNotFound)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestDeletePermission(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM permissions WHERE name = ?")).
+		WithArgs("products.get").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, service.DeletePermission(context.Background(), "products.get"), repository.ErrPermissionNotFound)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestRenameRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE roles SET name = ? WHERE name = ?")).
+		WithArgs("author", "editor").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, service.RenameRole(context.Background(), "editor", "author"))
+
+	// renaming to the same name only checks the role exists
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?)")).
+		WithArgs("editor").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	assert.ErrorIs(t, service.RenameRole(context.Background(), "editor", "editor"), repository.ErrRoleNotFound)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestRenamePermission(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE permissions SET name = ? WHERE name = ?")).
+		WithArgs("products.list", "products.get").
+		WillReturnError(fmt.Errorf("Error 1062: Duplicate entry 'products.list' for key 'name'"))
+	assert.ErrorIs(t, service.RenamePermission(context.Background(), "products.get", "products.list"), repository.ErrDuplicatePermission)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestGetRolePermissions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?)")).
+		WithArgs("editor").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE rhp.role_id = ?")).
+		WithArgs(2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "articles.get").AddRow(2, "articles.post"))
+
+	permissions, err := service.GetRolePermissions(context.Background(), "editor", repository.Pagination{Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Permission{{ID: 1, Name: "articles.get"}, {ID: 2, Name: "articles.post"}}, permissions)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestListUsersWithRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := confide_acl.ConfigACL{
+		Database:     db,
+		TableAccount: "users",
+	}
+	service, err := confide_acl.NewService(conf)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN (?)")).
+		WithArgs("ghost").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = service.ListUsersWithRole(context.Background(), "ghost", repository.Pagination{})
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+// expectUserRoles mocks the roles held directly by the user
+func expectUserRoles(mock sqlmock.Sqlmock, userID int, roles ...repository.Role) {
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for _, role := range roles {
+		rows.AddRow(role.ID, role.Name)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT r.id, r.name")).
+		WithArgs(userID).
+		WillReturnRows(rows)
+}
+
+// expectRoleHierarchy mocks the role_has_roles relations
+func expectRoleHierarchy(mock sqlmock.Sqlmock, relations ...repository.RoleHasRole) {
+	rows := sqlmock.NewRows([]string{"role_id", "parent_role_id"})
+	for _, relation := range relations {
+		rows.AddRow(relation.RoleID, relation.ParentRoleID)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT role_id, parent_role_id FROM role_has_roles")).
+		WillReturnRows(rows)
+}
+
+// expectRoleIDs mocks the lookup of role ids by name
+func expectRoleIDs(mock sqlmock.Sqlmock, names []string, ids ...uint) {
+	args := make([]driver.Value, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name IN")).
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+// expectRolesByName mocks the lookup of the roles of a policy by name
+func expectRolesByName(mock sqlmock.Sqlmock, names []string, roles ...repository.Role) {
+	args := make([]driver.Value, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for _, role := range roles {
+		rows.AddRow(role.ID, role.Name)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM roles WHERE name IN")).
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+// expectRolesPermissions mocks the permissions of a list of roles
+func expectRolesPermissions(mock sqlmock.Sqlmock, roleIDs []uint, permissions ...repository.Permission) {
+	args := make([]driver.Value, len(roleIDs))
+	for i, id := range roleIDs {
+		args[i] = id
+	}
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for _, permission := range permissions {
+		rows.AddRow(permission.ID, permission.Name)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT p.id, p.name")).
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+// expectNoDeny mocks the deny rules checked once the policy allows the request, nothing is denied.
+// the roles of the user are the ones already loaded by the role clauses of the policy
+func expectNoDeny(mock sqlmock.Sqlmock, userID int, roles ...repository.Role) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	if len(roles) > 0 {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM role_has_denied_permissions rdp")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	}
+}
+
+func TestPolicyACLSuperAdmin(t *testing.T) {
+	accountRoleQuery := regexp.QuoteMeta("a.full_name AS fullName")
+
+	tests := []struct {
+		name     string
+		conf     confide_acl.ConfigACL
+		mockFunc func(mock sqlmock.Sqlmock)
+		expected bool
+	}{
+		{
+			name: "Default super-admin role bypasses the policy",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountRoleQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).AddRow("root", "Superadmin"))
+			},
+			expected: true,
+		},
+		{
+			name: "Super-admin role is found after other roles",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountRoleQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).
+						AddRow("root", "viewer").
+						AddRow("root", "Superadmin"))
+			},
+			expected: true,
+		},
+		{
+			name: "Custom super-admin roles replace the defaults",
+			conf: confide_acl.ConfigACL{SuperAdminRoles: []string{"root"}},
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountRoleQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).AddRow("john", "Admin"))
+				expectUserRoles(mock, 1, repository.Role{ID: 1, Name: "Admin"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+			},
+			expected: false,
+		},
+		{
+			name: "Disabled bypass checks the policy",
+			conf: confide_acl.ConfigACL{DisableSuperAdmin: true},
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "products.get"})
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.conf.Database = db
+			svc, err := confide_acl.NewService(tt.conf)
+			require.NoError(t, err)
+
+			tt.mockFunc(mock)
+
+			allowed, err := svc.PolicyACL(context.Background(), 1, "role:editor", "products", "GET")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, allowed)
+
+			err = mock.ExpectationsWereMet()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicyACLExpression(t *testing.T) {
+	userPermissionQuery := regexp.QuoteMeta("FROM user_has_permissions uhp")
+
+	tests := []struct {
+		name          string
+		policy        string
+		mockFunc      func(mock sqlmock.Sqlmock)
+		expected      bool
+		expectedError error
+	}{
+		{
+			name:   "AND requires every clause",
+			policy: "role:editor AND permission:articles.publish",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				mock.ExpectQuery(userPermissionQuery).
+					WithArgs(1, "articles.publish", "articles.manage", "articles.*", "*.publish", "*.manage", "*").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			expected: false,
+		},
+		{
+			name:   "AND stops at the first denied clause",
+			policy: "role:editor AND permission:articles.publish",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1)
+			},
+			expected: false,
+		},
+		{
+			name:   "NOT negates the clause",
+			policy: "role:editor AND NOT role:suspended",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				// the roles of the user are read once for every role clause
+				expectRolesByName(mock, []string{"suspended"}, repository.Role{ID: 3, Name: "suspended"})
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+			},
+			expected: true,
+		},
+		{
+			name:   "NOT role checks the membership of the role",
+			policy: "role:editor AND NOT role:suspended",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				held := []repository.Role{{ID: 2, Name: "editor"}, {ID: 3, Name: "suspended"}}
+				expectUserRoles(mock, 1, held...)
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				// suspended grants nothing on articles, holding it is enough to refuse the access
+				expectRolesByName(mock, []string{"suspended"}, repository.Role{ID: 3, Name: "suspended"})
+			},
+			expected: false,
+		},
+		{
+			name:   "NOT alone allows the users without the role",
+			policy: "NOT role:suspended",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"suspended"}, repository.Role{ID: 3, Name: "suspended"})
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+			},
+			expected: true,
+		},
+		{
+			name:   "NOT alone refuses the users holding the role",
+			policy: "NOT role:suspended",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 3, Name: "suspended"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"suspended"}, repository.Role{ID: 3, Name: "suspended"})
+			},
+			expected: false,
+		},
+		{
+			name:   "NOT alone is overridden by a deny rule",
+			policy: "NOT role:suspended",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1)
+				mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "articles.publish"))
+			},
+			expected: false,
+		},
+		{
+			name:   "OR satisfied through NOT allows the user",
+			policy: "role:editor OR NOT role:suspended",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				// neither editor nor suspended, the NOT operand satisfies the OR
+				expectUserRoles(mock, 1)
+				expectNoDeny(mock, 1)
+			},
+			expected: true,
+		},
+		{
+			name:   "NOT NOT is the clause",
+			policy: "NOT NOT role:editor",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+			},
+			expected: true,
+		},
+		{
+			name:   "OR continues after a clause only satisfied through NOT",
+			policy: "NOT role:suspended OR permission:articles.publish",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1)
+				mock.ExpectQuery(userPermissionQuery).
+					WithArgs(1, "articles.publish", "articles.manage", "articles.*", "*.publish", "*.manage", "*").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "articles.publish"))
+				expectNoDeny(mock, 1)
+			},
+			expected: true,
+		},
+		{
+			name:   "NOT over a group of negated clauses keeps their grant",
+			policy: "NOT (NOT role:a AND NOT role:b)",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "a"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"a"}, repository.Role{ID: 2, Name: "a"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "a"})
+			},
+			expected: true,
+		},
+		{
+			name:   "NOT over a group grants through the clause under two NOT",
+			policy: "NOT (role:a AND NOT role:b)",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 3, Name: "b"})
+				expectRoleHierarchy(mock)
+				// a is not held, "NOT role:a" is satisfied without granting
+				expectRolesByName(mock, []string{"a"}, repository.Role{ID: 2, Name: "a"})
+				expectRolesByName(mock, []string{"b"}, repository.Role{ID: 3, Name: "b"})
+				expectRolesPermissions(mock, []uint{3}, repository.Permission{ID: 1, Name: "articles.publish"})
+				expectNoDeny(mock, 1, repository.Role{ID: 3, Name: "b"})
+			},
+			expected: true,
+		},
+		{
+			name:   "NOT over a group refuses the excluded role",
+			policy: "NOT (role:a AND NOT role:b)",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "a"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"a"}, repository.Role{ID: 2, Name: "a"})
+				expectRolesByName(mock, []string{"b"}, repository.Role{ID: 3, Name: "b"})
+			},
+			expected: false,
+		},
+		{
+			name:   "NOT permission checks the membership of the permission",
+			policy: "role:editor AND NOT permission:articles.delete",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				// articles.delete grants nothing on articles.publish, holding it is enough to refuse the access
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				mock.ExpectQuery(userPermissionQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "articles.delete"))
+			},
+			expected: false,
+		},
+		{
+			name:   "NOT permission is satisfied when the permission is not held",
+			policy: "role:editor AND NOT permission:articles.delete",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				mock.ExpectQuery(userPermissionQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+			},
+			expected: true,
+		},
+		{
+			name:   "Legacy syntax keeps the spaces of the names",
+			policy: "role:Super Admin",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "Super Admin"})
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"Super Admin"}, repository.Role{ID: 2, Name: "Super Admin"})
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.publish"})
+				expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "Super Admin"})
+			},
+			expected: true,
+		},
+		{
+			name:   "Legacy syntax is an OR",
+			policy: "role:editor|permission:articles.publish",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectUserRoles(mock, 1)
+				mock.ExpectQuery(userPermissionQuery).
+					WithArgs(1, "articles.publish", "articles.manage", "articles.*", "*.publish", "*.manage", "*").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "articles.publish"))
+				expectNoDeny(mock, 1)
+			},
+			expected: true,
+		},
+		{
+			name:          "Invalid expression",
+			policy:        "role:editor AND (permission:articles.publish",
+			mockFunc:      func(mock sqlmock.Sqlmock) {},
+			expectedError: confide_acl.ErrInvalidParseFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db, DisableSuperAdmin: true})
+			require.NoError(t, err)
+
+			tt.mockFunc(mock)
+
+			allowed, err := svc.PolicyACL(context.Background(), 1, tt.policy, "articles", "PUBLISH")
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.Equal(t, tt.expected, allowed)
+
+			err = mock.ExpectationsWereMet()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicyACLWildcard(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db, DisableSuperAdmin: true})
+	require.NoError(t, err)
+
+	// role grant through a module wildcard
+	expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+	expectRoleHierarchy(mock)
+	expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+	expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 7, Name: "products.*"})
+	expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+
+	allowed, err := svc.PolicyACL(context.Background(), 1, "role:editor", "products", "DELETE")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// direct grant through a method wildcard
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_permissions uhp")).
+		WithArgs(1, "orders.get", "orders.manage", "orders.read", "orders.*", "*.get", "*.manage", "*.read", "*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(8, "*.get"))
+	expectNoDeny(mock, 1)
+	// no role clause loaded the roles of the user, the deny rules do
+	expectUserRoles(mock, 1)
+
+	allowed, err = svc.PolicyACL(context.Background(), 1, "permission:orders.get", "orders", "GET")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+
+	// a wildcard held by the user doesn't satisfy a permission listed for another module
+	wildcards, err := confide_acl.NewService(confide_acl.ConfigACL{Store: memory.New(), DisableSuperAdmin: true})
+	require.NoError(t, err)
+	require.NoError(t, wildcards.AddPermission(context.Background(), "*.get"))
+	require.NoError(t, wildcards.AddPermission(context.Background(), "*"))
+	require.NoError(t, wildcards.GivePermissionToUser(context.Background(), 1, []string{"*.get", "*"}))
+
+	allowed, err = wildcards.PolicyACL(context.Background(), 1, "permission:orders.get", "products", "GET")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = wildcards.PolicyACL(context.Background(), 1, "permission:orders.get", "orders", "GET")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestPolicyACLVerbGroupsScope(t *testing.T) {
+	ctx := context.Background()
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Store: memory.New(), DisableSuperAdmin: true})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddPermission(ctx, "*.read"))
+	require.NoError(t, svc.GivePermissionToUser(ctx, 1, []string{"*.read"}))
+
+	// a verb group listed for another module doesn't grant the checked module
+	allowed, err := svc.PolicyACL(ctx, 1, "permission:orders.read", "products", "GET")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = svc.PolicyACL(ctx, 1, "permission:products.read", "products", "HEAD")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = svc.PolicyACL(ctx, 1, "permission:products.read", "products", "POST")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestPolicyACLRoleHierarchy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db, DisableSuperAdmin: true})
+	require.NoError(t, err)
+
+	// editor (2) inherits from author (3) which inherits from viewer (4)
+	hierarchy := []repository.RoleHasRole{{RoleID: 2, ParentRoleID: 3}, {RoleID: 3, ParentRoleID: 4}}
+
+	// an editor satisfies role:editor with a permission granted to viewer
+	expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+	expectRoleHierarchy(mock, hierarchy...)
+	expectRolesByName(mock, []string{"editor"}, repository.Role{ID: 2, Name: "editor"})
+	expectRolesPermissions(mock, []uint{2, 3, 4}, repository.Permission{ID: 1, Name: "articles.get"})
+	expectNoDeny(mock, 1, repository.Role{ID: 2, Name: "editor"})
+
+	allowed, err := svc.PolicyACL(context.Background(), 1, "role:editor", "articles", "GET")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// an editor satisfies role:viewer, only the viewer permissions are checked
+	expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+	expectRoleHierarchy(mock, hierarchy...)
+	expectRolesByName(mock, []string{"viewer"}, repository.Role{ID: 4, Name: "viewer"})
+	expectRolesPermissions(mock, []uint{4}, repository.Permission{ID: 1, Name: "articles.get"})
+
+	allowed, err = svc.PolicyACL(context.Background(), 1, "role:viewer", "articles", "PUBLISH")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestExplain(t *testing.T) {
+	accountRoleQuery := regexp.QuoteMeta("a.full_name AS fullName")
+	editor := repository.Role{ID: 2, Name: "editor"}
+
+	tests := []struct {
+		name          string
+		policy        string
+		mockFunc      func(mock sqlmock.Sqlmock)
+		expected      confide_acl.Decision
+		expectedError error
+	}{
+		{
+			name:   "Super-admin bypass",
+			policy: "role:editor",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountRoleQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).AddRow("root", "Admin"))
+			},
+			expected: confide_acl.Decision{
+				Allowed:        true,
+				Reason:         confide_acl.ReasonSuperAdmin,
+				Policy:         "role:editor",
+				Module:         "articles",
+				Method:         "publish",
+				SuperAdminRole: "Admin",
+			},
+		},
+		{
+			name:   "Granted by the second clause of an OR",
+			policy: "role:editor OR permission:articles.publish",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountRoleQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).AddRow("john", "editor"))
+				expectUserRoles(mock, 1, editor)
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, editor)
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.get"})
+				mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_permissions uhp")).
+					WithArgs(1, "articles.publish", "articles.manage", "articles.*", "*.publish", "*.manage", "*").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "articles.*"))
+				expectNoDeny(mock, 1, editor)
+			},
+			expected: confide_acl.Decision{
+				Allowed:     true,
+				Reason:      confide_acl.ReasonGranted,
+				Policy:      "(role:editor OR permission:articles.publish)",
+				Module:      "articles",
+				Method:      "publish",
+				MatchedRule: "permission:articles.publish",
+				Permission:  "articles.*",
+				Clauses: []confide_acl.ClauseResult{
+					{Clause: "role:editor"},
+					{Clause: "permission:articles.publish", Satisfied: true, Permission: "articles.*"},
+				},
+			},
+		},
+		{
+			name:   "Denied by a role deny rule",
+			policy: "role:editor",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountRoleQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"full_name", "role_name"}).AddRow("john", "editor"))
+				expectUserRoles(mock, 1, editor)
+				expectRoleHierarchy(mock)
+				expectRolesByName(mock, []string{"editor"}, editor)
+				expectRolesPermissions(mock, []uint{2}, repository.Permission{ID: 1, Name: "articles.*"})
+				mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+				mock.ExpectQuery(regexp.QuoteMeta("FROM role_has_denied_permissions rdp")).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "articles.publish"))
+			},
+			expected: confide_acl.Decision{
+				Reason:           confide_acl.ReasonDenied,
+				Policy:           "role:editor",
+				Module:           "articles",
+				Method:           "publish",
+				MatchedRule:      "role:editor",
+				Role:             "editor",
+				Permission:       "articles.*",
+				DeniedPermission: "articles.publish",
+				DeniedBy:         confide_acl.DeniedByRole,
+				Clauses: []confide_acl.ClauseResult{
+					{Clause: "role:editor", Satisfied: true, Role: "editor", Permission: "articles.*"},
+				},
+			},
+		},
+		{
+			name:          "Invalid policy",
+			policy:        "role:editor AND",
+			mockFunc:      func(mock sqlmock.Sqlmock) {},
+			expected:      confide_acl.Decision{Reason: confide_acl.ReasonInvalidPolicy, Module: "articles", Method: "publish"},
+			expectedError: confide_acl.ErrInvalidParseFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db})
+			require.NoError(t, err)
+
+			tt.mockFunc(mock)
+
+			decision, err := svc.Explain(context.Background(), 1, tt.policy, "articles", "PUBLISH")
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.Equal(t, tt.expected, decision)
+
+			err = mock.ExpectationsWereMet()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSetRoleParents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db})
+	require.NoError(t, err)
+
+	// viewer (4) can't inherit from editor (2) which already inherits from viewer
+	expectRoleIDs(mock, []string{"viewer"}, 4)
+	expectRoleIDs(mock, []string{"editor"}, 2)
+	expectRoleHierarchy(mock, repository.RoleHasRole{RoleID: 2, ParentRoleID: 4})
+
+	err = svc.SetRoleParents(context.Background(), "viewer", []string{"editor"})
+	assert.ErrorIs(t, err, confide_acl.ErrRoleHierarchyCycle)
+	assert.ErrorIs(t, err, confide_acl.ErrInvalidArgument)
+	var aclErr *confide_acl.Error
+	require.ErrorAs(t, err, &aclErr)
+	assert.Equal(t, confide_acl.KindRole, aclErr.Kind)
+	assert.Equal(t, "viewer", aclErr.Name)
+
+	// a role can't be its own parent
+	expectRoleIDs(mock, []string{"viewer"}, 4)
+	expectRoleIDs(mock, []string{"viewer"}, 4)
+	expectRoleHierarchy(mock)
+
+	err = svc.SetRoleParents(context.Background(), "viewer", []string{"viewer"})
+	assert.ErrorIs(t, err, confide_acl.ErrRoleHierarchyCycle)
+
+	// unknown parent
+	expectRoleIDs(mock, []string{"editor"}, 2)
+	expectRoleIDs(mock, []string{"viewer", "ghost"}, 4)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT name FROM roles WHERE name IN (?,?)")).
+		WithArgs("viewer", "ghost").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("viewer"))
+
+	err = svc.SetRoleParents(context.Background(), "editor", []string{"viewer", "ghost"})
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+	assert.EqualError(t, err, `role "ghost" not found`)
+
+	// editor inherits from viewer
+	expectRoleIDs(mock, []string{"editor"}, 2)
+	expectRoleIDs(mock, []string{"viewer"}, 4)
+	expectRoleHierarchy(mock)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM role_has_roles WHERE role_id = ?")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO role_has_roles (role_id, parent_role_id) VALUES (?, ?)")).
+		WithArgs(2, 4).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = svc.SetRoleParents(context.Background(), "editor", []string{"viewer"})
+	require.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestGetUserPermissions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db})
+	require.NoError(t, err)
+
+	expectUserRoles(mock, 1, repository.Role{ID: 2, Name: "editor"})
+	expectRoleHierarchy(mock, repository.RoleHasRole{RoleID: 2, ParentRoleID: 4})
+	expectRolesPermissions(mock, []uint{2, 4},
+		repository.Permission{ID: 1, Name: "articles.get"},
+		repository.Permission{ID: 3, Name: "articles.publish"})
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_permissions uhp")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(2, "articles.delete").
+			AddRow(3, "articles.publish"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM role_has_denied_permissions rdp")).
+		WithArgs(2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	permissions, err := svc.GetUserPermissions(context.Background(), 1, repository.Pagination{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Permission{{ID: 2, Name: "articles.delete"}, {ID: 3, Name: "articles.publish"}}, permissions)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestGetUserPermissionsDenied(t *testing.T) {
+	ctx := context.Background()
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Store: memory.New()})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddRole(ctx, "editor"))
+	for _, permission := range []string{"articles.get", "articles.delete", "articles.*", "orders.get", "*.delete"} {
+		require.NoError(t, svc.AddPermission(ctx, permission))
+	}
+	require.NoError(t, svc.AssignPermissionToRole(ctx, "editor", []string{"articles.get", "articles.delete", "articles.*"}))
+	require.NoError(t, svc.AssignUserToRole(ctx, 1, "editor"))
+	require.NoError(t, svc.GivePermissionToUser(ctx, 1, []string{"orders.get"}))
+
+	// a wildcard deny removes the permissions it covers, a partial one keeps articles.*
+	require.NoError(t, svc.DenyPermissionToUser(ctx, 1, []string{"*.delete"}))
+	require.NoError(t, svc.DenyPermissionToRole(ctx, "editor", []string{"orders.get"}))
+
+	permissions, err := svc.GetUserPermissions(ctx, 1, repository.Pagination{})
+	require.NoError(t, err)
+
+	var names []string
+	for _, permission := range permissions {
+		names = append(names, permission.Name)
+	}
+	assert.Equal(t, []string{"articles.get", "articles.*"}, names)
+
+	allowed, err := svc.PolicyACL(ctx, 1, "role:editor", "articles", "DELETE")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestPolicyACLDeny(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db, DisableSuperAdmin: true})
+	require.NoError(t, err)
+
+	support := repository.Role{ID: 5, Name: "support"}
+
+	// support can do everything in tickets except tickets.delete
+	grantTickets := func() {
+		expectUserRoles(mock, 1, support)
+		expectRoleHierarchy(mock)
+		expectRolesByName(mock, []string{"support"}, repository.Role{ID: 5, Name: "support"})
+		expectRolesPermissions(mock, []uint{5}, repository.Permission{ID: 1, Name: "tickets.*"})
+	}
+
+	grantTickets()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM role_has_denied_permissions rdp")).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "tickets.delete"))
+
+	allowed, err := svc.PolicyACL(context.Background(), 1, "role:support", "tickets", "DELETE")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// the deny doesn't match tickets.get
+	grantTickets()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM role_has_denied_permissions rdp")).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "tickets.delete"))
+
+	allowed, err = svc.PolicyACL(context.Background(), 1, "role:support", "tickets", "GET")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// a wildcard denied to the user overrides the role grant
+	grantTickets()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_has_denied_permissions udp")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "*"))
+
+	allowed, err = svc.PolicyACL(context.Background(), 1, "role:support", "tickets", "GET")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestDenyPermissionToRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db})
+	require.NoError(t, err)
+
+	expectRoleIDs(mock, []string{"support"}, 5)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM permissions WHERE name IN (?)")).
+		WithArgs("tickets.delete").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO role_has_denied_permissions (role_id, permission_id) VALUES (?, ?)")).
+		WithArgs(5, 2).
+		WillReturnError(fmt.Errorf("Error 1062: Duplicate entry '5-2' for key 'PRIMARY'"))
+	mock.ExpectRollback()
+
+	err = svc.DenyPermissionToRole(context.Background(), "support", []string{"tickets.delete"})
+	assert.ErrorIs(t, err, repository.ErrDuplicateDeniedPermission)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestRemoveDenyFromUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db})
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM permissions WHERE name IN (?)")).
+		WithArgs("tickets.delete").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM user_has_denied_permissions WHERE user_id = ? AND permission_id = ?")).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = svc.RemoveDenyFromUser(context.Background(), 1, []string{"tickets.delete"})
+	require.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	require.NoError(t, err)
+}
+
+func TestTypedErrors(t *testing.T) {
+	ctx := context.Background()
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Store: memory.New()})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddRole(ctx, "editor"))
+	require.NoError(t, svc.AddPermission(ctx, "articles.publish"))
+	require.NoError(t, svc.AssignPermissionToRole(ctx, "editor", []string{"articles.publish"}))
+
+	var aclErr *confide_acl.Error
+
+	err = svc.AddRole(ctx, "editor")
+	assert.ErrorIs(t, err, confide_acl.ErrAlreadyExists)
+	assert.ErrorIs(t, err, repository.ErrDuplicateRole)
+	require.ErrorAs(t, err, &aclErr)
+	assert.Equal(t, confide_acl.KindRole, aclErr.Kind)
+	assert.Equal(t, "editor", aclErr.Name)
+	assert.EqualError(t, err, `role "editor" already exists`)
+
+	err = svc.AssignPermissionToRole(ctx, "editor", []string{"articles.publish"})
+	assert.ErrorIs(t, err, confide_acl.ErrAlreadyExists)
+	require.ErrorAs(t, err, &aclErr)
+	assert.Equal(t, confide_acl.KindRolePermission, aclErr.Kind)
+
+	err = svc.AssignUserToRole(ctx, 1, "ghost")
+	assert.ErrorIs(t, err, confide_acl.ErrNotFound)
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+	assert.EqualError(t, err, `role "ghost" not found`)
+
+	err = svc.RemoveUserFromRole(ctx, 1, "editor")
+	assert.ErrorIs(t, err, confide_acl.ErrNotFound)
+	require.ErrorAs(t, err, &aclErr)
+	assert.Equal(t, confide_acl.KindUserRole, aclErr.Kind)
+
+	_, err = svc.PolicyACL(ctx, 1, "role:editor AND", "articles", "publish")
+	assert.ErrorIs(t, err, confide_acl.ErrInvalidPolicy)
+	assert.ErrorIs(t, err, confide_acl.ErrInvalidParseFormat)
+	require.ErrorAs(t, err, &aclErr)
+	assert.Equal(t, confide_acl.KindPolicy, aclErr.Kind)
+}
+
+func TestTypedErrorsStorage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Database: db, Dialect: repository.DialectPostgres})
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO roles (name) VALUES ($1)")).
+		WithArgs("editor").
+		WillReturnError(assert.AnError)
+
+	err = svc.AddRole(context.Background(), "editor")
+	assert.ErrorIs(t, err, confide_acl.ErrStorage)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NotErrorIs(t, err, confide_acl.ErrAlreadyExists)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignPermissionToRoleMissing(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Store: store})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddRole(ctx, "editor"))
+	require.NoError(t, svc.AddPermission(ctx, "articles.get"))
+
+	// nothing is assigned when one of the permissions does not exist
+	err = svc.AssignPermissionToRole(ctx, "editor", []string{"articles.get", "typo.get"})
+	assert.ErrorIs(t, err, confide_acl.ErrNotFound)
+	assert.ErrorIs(t, err, repository.ErrPermissionNotFound)
+	var aclErr *confide_acl.Error
+	require.ErrorAs(t, err, &aclErr)
+	assert.Equal(t, confide_acl.KindPermission, aclErr.Kind)
+	assert.Equal(t, "typo.get", aclErr.Name)
+
+	permissions, err := svc.GetRolePermissions(ctx, "editor", repository.Pagination{})
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	// the missing permissions are skipped on request
+	err = svc.AssignPermissionToRole(ctx, "editor", []string{"articles.get", "typo.get"}, confide_acl.SkipMissingPermissions())
+	require.NoError(t, err)
+
+	permissions, err = svc.GetRolePermissions(ctx, "editor", repository.Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, []repository.Permission{{ID: 1, Name: "articles.get"}}, permissions)
+
+	// nothing to assign
+	err = svc.AssignPermissionToRole(ctx, "editor", []string{"typo.get"}, confide_acl.SkipMissingPermissions())
+	assert.ErrorIs(t, err, confide_acl.ErrNotFound)
+}
+
+func TestPolicyACLPathNormalizer(t *testing.T) {
+	ctx := context.Background()
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{
+		Store:             memory.New(),
+		DisableSuperAdmin: true,
+		PathNormalizer:    &confide_acl.PathNormalizer{Prefixes: []string{"/api"}, StripVersion: true},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddRole(ctx, "editor"))
+	require.NoError(t, svc.AddPermission(ctx, "products.get"))
+	require.NoError(t, svc.AssignPermissionToRole(ctx, "editor", []string{"products.get"}))
+	require.NoError(t, svc.AssignUserToRole(ctx, 1, "editor"))
+
+	allowed, err := svc.PolicyACL(ctx, 1, "role:editor", "/api/v1/products/17", "GET")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = svc.PolicyACL(ctx, 1, "role:editor", "/api/v1/products/17", "DELETE")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	decision, err := svc.Explain(ctx, 1, "role:editor", "/api/v1/Products", "GET")
+	require.NoError(t, err)
+	assert.Equal(t, "products", decision.Module)
+	assert.Equal(t, "products.get", decision.Permission)
+}
+
+func TestPolicyACLVerbGroups(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		config   confide_acl.ConfigACL
+		method   string
+		expected bool
+	}{
+		{name: "Read grants GET", method: "GET", expected: true},
+		{name: "Read grants HEAD", method: "HEAD", expected: true},
+		{name: "Read grants OPTIONS", method: "OPTIONS", expected: true},
+		{name: "Read does not grant POST", method: "POST", expected: false},
+		{name: "Manage grants DELETE", method: "DELETE", expected: true},
+		{name: "Custom groups", config: confide_acl.ConfigACL{VerbGroups: map[string][]string{"read": {"GET"}}}, method: "HEAD", expected: false},
+		{name: "Disabled", config: confide_acl.ConfigACL{DisableVerbGroups: true}, method: "GET", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Store = memory.New()
+			tt.config.DisableSuperAdmin = true
+			svc, err := confide_acl.NewService(tt.config)
+			require.NoError(t, err)
+
+			require.NoError(t, svc.AddRole(ctx, "viewer"))
+			require.NoError(t, svc.AddPermission(ctx, "products.read"))
+			require.NoError(t, svc.AddPermission(ctx, "orders.manage"))
+			require.NoError(t, svc.AssignPermissionToRole(ctx, "viewer", []string{"products.read"}))
+			require.NoError(t, svc.AssignUserToRole(ctx, 1, "viewer"))
+			require.NoError(t, svc.GivePermissionToUser(ctx, 1, []string{"orders.manage"}))
+
+			module, policy := "products", "role:viewer"
+			if tt.method == "DELETE" {
+				module, policy = "orders", "permission:orders.delete"
+			}
+
+			allowed, err := svc.PolicyACL(ctx, 1, policy, module, tt.method)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, allowed)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package confide_acl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cangkir13/confide_acl"
+	"github.com/cangkir13/confide_acl/repository"
+	"github.com/cangkir13/confide_acl/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roleStore is a custom store keeping the created roles in memory
+type roleStore struct {
+	repository.RepositoryService
+	roles []string
+}
+
+func (s *roleStore) CreateRole(ctx context.Context, name string) error {
+	for _, role := range s.roles {
+		if role == name {
+			return repository.ErrDuplicateRole
+		}
+	}
+	s.roles = append(s.roles, name)
+	return nil
+}
+
+func TestCustomStore(t *testing.T) {
+	store := &roleStore{}
+	service, err := confide_acl.NewService(confide_acl.ConfigACL{Store: store})
+	require.NoError(t, err)
+
+	err = service.AddRole(context.Background(), "editor")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, store.roles)
+
+	err = service.AddRole(context.Background(), "editor")
+	assert.ErrorIs(t, err, repository.ErrDuplicateRole)
+}
+
+func TestNewServiceInvalidConfig(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = confide_acl.NewService(confide_acl.ConfigACL{Database: db, TableAccount: "users; DROP TABLE roles"})
+	assert.ErrorIs(t, err, repository.ErrInvalidIdentifier)
+
+	_, err = confide_acl.NewService(confide_acl.ConfigACL{Database: db, Dialect: "oracle"})
+	assert.ErrorIs(t, err, repository.ErrUnsupportedDialect)
+}
+
+func TestPolicyACLMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	svc, err := confide_acl.NewService(confide_acl.ConfigACL{Store: memory.New()})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddRole(ctx, "Admin"))
+	require.NoError(t, svc.AddRole(ctx, "viewer"))
+	require.NoError(t, svc.AddRole(ctx, "editor"))
+	require.NoError(t, svc.AddPermission(ctx, "articles.get"))
+	require.NoError(t, svc.AddPermission(ctx, "articles.*"))
+	require.NoError(t, svc.AssignPermissionToRole(ctx, "viewer", []string{"articles.get"}))
+	require.NoError(t, svc.AssignPermissionToRole(ctx, "editor", []string{"articles.*"}))
+	require.NoError(t, svc.SetRoleParents(ctx, "editor", []string{"viewer"}))
+	require.NoError(t, svc.AssignUserToRole(ctx, 1, "editor"))
+	require.NoError(t, svc.AssignUserToRole(ctx, 2, "Admin"))
+
+	allowed, err := svc.PolicyACL(ctx, 1, "role:viewer", "articles", "GET")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NoError(t, svc.DenyPermissionToUser(ctx, 1, []string{"articles.*"}))
+
+	allowed, err = svc.PolicyACL(ctx, 1, "role:editor", "articles", "DELETE")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = svc.PolicyACL(ctx, 2, "role:editor", "articles", "DELETE")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// deleting the role removes it from the user
+	require.NoError(t, svc.DeleteRole(ctx, "editor"))
+
+	roles, err := svc.GetUserRoles(ctx, 1, repository.Pagination{})
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+}
@@ -0,0 +1,200 @@
+package subject
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned by a JWT extractor when the token is malformed, badly signed or expired.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrInvalidKey is returned by HS256 for an empty secret and by RS256 when the public key can't verify a signature.
+	ErrInvalidKey = errors.New("invalid key")
+)
+
+// Signing algorithms of the JWT extractors
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+// JWT is an Extractor reading the user ID from a claim of a verified JSON Web Token,
+// sent as "Authorization: Bearer <token>". build it with HS256 or RS256.
+// only the algorithm of the extractor is accepted, the "exp" and "nbf" claims are checked when present.
+type JWT struct {
+	alg    string
+	verify func(signed, signature []byte) bool
+	claim  string
+
+	// Header is the header carrying the token, "Authorization" by default
+	Header string
+	// Leeway is the clock skew tolerated on "exp" and "nbf"
+	Leeway time.Duration
+	// Now returns the current time, time.Now by default
+	Now func() time.Time
+}
+
+// HS256 returns a JWT extractor verifying the tokens signed with HMAC SHA-256 and secret,
+// the user ID is read from claim, like "sub". ErrInvalidKey is returned for a nil or empty secret.
+func HS256(secret []byte, claim string) (*JWT, error) {
+	if len(secret) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return &JWT{
+		alg: algHS256,
+		verify: func(signed, signature []byte) bool {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write(signed)
+			return hmac.Equal(mac.Sum(nil), signature)
+		},
+		claim: claim,
+	}, nil
+}
+
+// RS256 returns a JWT extractor verifying the tokens signed with RSA PKCS #1 v1.5 SHA-256 by the private key
+// of key, the user ID is read from claim, like "sub". ErrInvalidKey is returned for a nil or incomplete key.
+func RS256(key *rsa.PublicKey, claim string) (*JWT, error) {
+	if key == nil || key.N == nil || key.N.Sign() <= 0 || key.E < 2 {
+		return nil, ErrInvalidKey
+	}
+
+	return &JWT{
+		alg: algRS256,
+		verify: func(signed, signature []byte) bool {
+			digest := sha256.Sum256(signed)
+			return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+		},
+		claim: claim,
+	}, nil
+}
+
+// Extract verifies the bearer token of the carrier and returns the user ID of its claim.
+// ErrNoSubject is returned without token, ErrInvalidToken when the token can't be trusted.
+func (j *JWT) Extract(ctx context.Context, carrier Carrier) (int, error) {
+	header := j.Header
+	if header == "" {
+		header = "Authorization"
+	}
+
+	token, ok := bearerToken(carrier.Get(header))
+	if !ok {
+		return 0, ErrNoSubject
+	}
+
+	claims, err := j.parse(token)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := claims[j.claim]
+	if !ok {
+		return 0, fmt.Errorf("%w: no %q claim", ErrInvalidSubject, j.claim)
+	}
+	return toUserID(value)
+}
+
+// parse verifies the signature and the time claims of a token and returns its claims.
+func (j *JWT) parse(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if header.Alg != j.alg {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	if !j.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+
+	now := time.Now()
+	if j.Now != nil {
+		now = j.Now()
+	}
+
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return nil, err
+	} else if ok && !now.Before(exp.Add(j.Leeway)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return nil, err
+	} else if ok && now.Add(j.Leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" value.
+func bearerToken(value string) (string, bool) {
+	const prefix = "bearer "
+	if len(value) <= len(prefix) || !strings.EqualFold(value[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(value[len(prefix):]), true
+}
+
+// decodeSegment decodes a base64url JSON segment of a token, the numbers are kept as json.Number.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// maxNumericDate is the NumericDate of 9999-12-31T23:59:59Z, the later dates are capped to it.
+const maxNumericDate = 253402300799
+
+// numericDate returns the time of a NumericDate claim, ok is false when the claim is absent.
+func numericDate(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %q is not a number", ErrInvalidToken, name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %q is not a number", ErrInvalidToken, name)
+	}
+	// dates after the year 9999, like the "never expires" 9999999999, are capped so the conversion can't overflow
+	seconds = math.Max(-maxNumericDate, math.Min(seconds, maxNumericDate))
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*float64(time.Second))), true, nil
+}
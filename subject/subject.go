@@ -0,0 +1,161 @@
+// Package subject extracts the user ID checked by confide_acl from a request, the same extractors serve
+// the net/http middleware of aclhttp and the interceptors of other transports like gRPC.
+//
+// Example:
+//
+//	jwt, err := subject.HS256([]byte(os.Getenv("JWT_SECRET")), "sub")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	extractor := subject.First(subject.FromContext, jwt)
+//	authz := aclhttp.New(acl, aclhttp.WithSubject(extractor))
+package subject
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoSubject is returned by an Extractor when the request does not carry a subject.
+	ErrNoSubject = errors.New("no subject in the request")
+	// ErrInvalidSubject is returned by an Extractor when the subject is not a user ID.
+	ErrInvalidSubject = errors.New("invalid subject")
+)
+
+// Carrier gives access to the values sent with a request, the headers of an HTTP request or
+// the metadata of a gRPC call.
+type Carrier interface {
+	// Get returns the first value of key, an empty string if there is none
+	Get(key string) string
+}
+
+// HeaderCarrier is the Carrier of the headers of an HTTP request.
+type HeaderCarrier http.Header
+
+// Get returns the first value of the header key.
+func (h HeaderCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+// MetadataCarrier is the Carrier of metadata with lower case keys, like the metadata.MD of a gRPC call.
+type MetadataCarrier map[string][]string
+
+// Get returns the first value of key, the key is lower cased.
+func (m MetadataCarrier) Get(key string) string {
+	values := m[strings.ToLower(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Extractor returns the ID of the user sending a request.
+type Extractor interface {
+	// Extract returns the user ID, ErrNoSubject if the request does not carry one
+	Extract(ctx context.Context, carrier Carrier) (int, error)
+}
+
+// ExtractorFunc adapts a function to an Extractor.
+type ExtractorFunc func(ctx context.Context, carrier Carrier) (int, error)
+
+// Extract calls f.
+func (f ExtractorFunc) Extract(ctx context.Context, carrier Carrier) (int, error) {
+	return f(ctx, carrier)
+}
+
+// subjectKey is the context key of the user ID set by NewContext
+type subjectKey struct{}
+
+// NewContext returns a copy of ctx carrying the user ID read by FromContext.
+func NewContext(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, subjectKey{}, userID)
+}
+
+// FromContext reads the user ID set by NewContext.
+var FromContext Extractor = ContextValue(subjectKey{})
+
+// ContextValue returns an Extractor reading the user ID from the context value of key, set by a session
+// or authentication middleware. the value can be an int, int64, uint or a decimal string.
+func ContextValue(key interface{}) Extractor {
+	return ExtractorFunc(func(ctx context.Context, carrier Carrier) (int, error) {
+		value := ctx.Value(key)
+		if value == nil {
+			return 0, ErrNoSubject
+		}
+		return toUserID(value)
+	})
+}
+
+// Header returns an Extractor reading the user ID from a header, like "X-User-ID".
+// the header is sent by the client, only use it behind a gateway authenticating the requests and setting it.
+func Header(name string) Extractor {
+	return ExtractorFunc(func(ctx context.Context, carrier Carrier) (int, error) {
+		value := carrier.Get(name)
+		if value == "" {
+			return 0, ErrNoSubject
+		}
+		return toUserID(value)
+	})
+}
+
+// First returns an Extractor trying the extractors in order, the first one finding a subject wins.
+// an error other than ErrNoSubject stops the extraction.
+func First(extractors ...Extractor) Extractor {
+	return ExtractorFunc(func(ctx context.Context, carrier Carrier) (int, error) {
+		for _, extractor := range extractors {
+			userID, err := extractor.Extract(ctx, carrier)
+			if errors.Is(err, ErrNoSubject) {
+				continue
+			}
+			return userID, err
+		}
+		return 0, ErrNoSubject
+	})
+}
+
+// toUserID converts a context value or a claim to a user ID, the IDs not positive or overflowing an int are refused.
+func toUserID(value interface{}) (int, error) {
+	var userID int64
+	switch v := value.(type) {
+	case int:
+		userID = int64(v)
+	case int64:
+		userID = v
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %d overflows an int", ErrInvalidSubject, v)
+		}
+		userID = int64(v)
+	case json.Number:
+		// numeric claims of a JWT
+		parsed, err := strconv.ParseInt(v.String(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidSubject, v)
+		}
+		userID = parsed
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidSubject, v)
+		}
+		userID = parsed
+	default:
+		return 0, fmt.Errorf("%w: %T", ErrInvalidSubject, value)
+	}
+
+	if userID <= 0 {
+		return 0, fmt.Errorf("%w: %d is not a positive user ID", ErrInvalidSubject, userID)
+	}
+	if userID > math.MaxInt {
+		return 0, fmt.Errorf("%w: %d overflows an int", ErrInvalidSubject, userID)
+	}
+	return int(userID), nil
+}
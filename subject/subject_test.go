@@ -0,0 +1,236 @@
+package subject_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cangkir13/confide_acl/subject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("secret")
+
+// token builds a JWT with the header alg and the claims, signed by sign
+func token(t *testing.T, alg string, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(signed []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(signed)
+	return mac.Sum(nil)
+}
+
+func bearer(token string) subject.Carrier {
+	return subject.HeaderCarrier(http.Header{"Authorization": {"Bearer " + token}})
+}
+
+func TestContextValue(t *testing.T) {
+	ctx := context.Background()
+
+	userID, err := subject.FromContext.Extract(subject.NewContext(ctx, 7), subject.HeaderCarrier{})
+	require.NoError(t, err)
+	assert.Equal(t, 7, userID)
+
+	_, err = subject.FromContext.Extract(ctx, subject.HeaderCarrier{})
+	assert.ErrorIs(t, err, subject.ErrNoSubject)
+
+	type sessionKey struct{}
+	userID, err = subject.ContextValue(sessionKey{}).Extract(context.WithValue(ctx, sessionKey{}, "12"), subject.HeaderCarrier{})
+	require.NoError(t, err)
+	assert.Equal(t, 12, userID)
+
+	_, err = subject.ContextValue(sessionKey{}).Extract(context.WithValue(ctx, sessionKey{}, 1.5), subject.HeaderCarrier{})
+	assert.ErrorIs(t, err, subject.ErrInvalidSubject)
+}
+
+func TestHeader(t *testing.T) {
+	extractor := subject.Header("X-User-ID")
+
+	userID, err := extractor.Extract(context.Background(), subject.HeaderCarrier(http.Header{"X-User-Id": {"3"}}))
+	require.NoError(t, err)
+	assert.Equal(t, 3, userID)
+
+	// gRPC metadata keys are lower case
+	userID, err = extractor.Extract(context.Background(), subject.MetadataCarrier{"x-user-id": {"4"}})
+	require.NoError(t, err)
+	assert.Equal(t, 4, userID)
+
+	_, err = extractor.Extract(context.Background(), subject.MetadataCarrier{})
+	assert.ErrorIs(t, err, subject.ErrNoSubject)
+
+	_, err = extractor.Extract(context.Background(), subject.MetadataCarrier{"x-user-id": {"admin"}})
+	assert.ErrorIs(t, err, subject.ErrInvalidSubject)
+
+	for _, value := range []string{"0", "-3", "99999999999999999999"} {
+		_, err = extractor.Extract(context.Background(), subject.MetadataCarrier{"x-user-id": {value}})
+		assert.ErrorIs(t, err, subject.ErrInvalidSubject, value)
+	}
+}
+
+func TestHS256(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	extractor, err := subject.HS256(secret, "sub")
+	require.NoError(t, err)
+	extractor.Now = func() time.Time { return now }
+
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+		err      error
+	}{
+		{
+			name:     "valid",
+			token:    token(t, "HS256", map[string]interface{}{"sub": 42, "exp": now.Unix() + 60}, hs256),
+			expected: 42,
+		},
+		{
+			name:     "string claim",
+			token:    token(t, "HS256", map[string]interface{}{"sub": "42"}, hs256),
+			expected: 42,
+		},
+		{
+			name: "bad signature",
+			token: token(t, "HS256", map[string]interface{}{"sub": 42}, func(signed []byte) []byte {
+				mac := hmac.New(sha256.New, []byte("other"))
+				mac.Write(signed)
+				return mac.Sum(nil)
+			}),
+			err: subject.ErrInvalidToken,
+		},
+		{
+			name:  "alg none",
+			token: token(t, "none", map[string]interface{}{"sub": 42}, func([]byte) []byte { return nil }),
+			err:   subject.ErrInvalidToken,
+		},
+		{
+			name:  "expired",
+			token: token(t, "HS256", map[string]interface{}{"sub": 42, "exp": now.Unix() - 1}, hs256),
+			err:   subject.ErrInvalidToken,
+		},
+		{
+			name:     "expiring after the year 2262",
+			token:    token(t, "HS256", map[string]interface{}{"sub": 42, "exp": 9999999999}, hs256),
+			expected: 42,
+		},
+		{
+			name:     "expiring far after the year 9999",
+			token:    token(t, "HS256", map[string]interface{}{"sub": 42, "exp": 1e300}, hs256),
+			expected: 42,
+		},
+		{
+			name:     "fractional expiry",
+			token:    token(t, "HS256", map[string]interface{}{"sub": 42, "exp": float64(now.Unix()) + 0.5}, hs256),
+			expected: 42,
+		},
+		{
+			name:  "not valid yet",
+			token: token(t, "HS256", map[string]interface{}{"sub": 42, "nbf": now.Unix() + 60}, hs256),
+			err:   subject.ErrInvalidToken,
+		},
+		{
+			name:  "missing claim",
+			token: token(t, "HS256", map[string]interface{}{"user": 42}, hs256),
+			err:   subject.ErrInvalidSubject,
+		},
+		{
+			name:  "negative claim",
+			token: token(t, "HS256", map[string]interface{}{"sub": -42}, hs256),
+			err:   subject.ErrInvalidSubject,
+		},
+		{
+			name:  "claim overflowing an int",
+			token: token(t, "HS256", map[string]interface{}{"sub": json.Number("99999999999999999999")}, hs256),
+			err:   subject.ErrInvalidSubject,
+		},
+		{
+			name:  "malformed",
+			token: "not.a-token",
+			err:   subject.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, err := extractor.Extract(context.Background(), bearer(tt.token))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, userID)
+		})
+	}
+
+	_, err = extractor.Extract(context.Background(), subject.HeaderCarrier{})
+	assert.ErrorIs(t, err, subject.ErrNoSubject)
+
+	_, err = subject.HS256(nil, "sub")
+	assert.ErrorIs(t, err, subject.ErrInvalidKey)
+
+	_, err = subject.HS256([]byte{}, "sub")
+	assert.ErrorIs(t, err, subject.ErrInvalidKey)
+}
+
+func TestRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rs256 := func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signature
+	}
+
+	extractor, err := subject.RS256(&key.PublicKey, "uid")
+	require.NoError(t, err)
+
+	userID, err := extractor.Extract(context.Background(), bearer(token(t, "RS256", map[string]interface{}{"uid": 9}, rs256)))
+	require.NoError(t, err)
+	assert.Equal(t, 9, userID)
+
+	// a token signed with the public key as HMAC secret is refused
+	_, err = extractor.Extract(context.Background(), bearer(token(t, "HS256", map[string]interface{}{"uid": 9}, hs256)))
+	assert.ErrorIs(t, err, subject.ErrInvalidToken)
+
+	_, err = subject.RS256(nil, "uid")
+	assert.ErrorIs(t, err, subject.ErrInvalidKey)
+
+	_, err = subject.RS256(&rsa.PublicKey{}, "uid")
+	assert.ErrorIs(t, err, subject.ErrInvalidKey)
+}
+
+func TestFirst(t *testing.T) {
+	jwt, err := subject.HS256(secret, "sub")
+	require.NoError(t, err)
+	extractor := subject.First(subject.FromContext, jwt)
+
+	userID, err := extractor.Extract(subject.NewContext(context.Background(), 1), bearer(token(t, "HS256", map[string]interface{}{"sub": 2}, hs256)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	userID, err = extractor.Extract(context.Background(), bearer(token(t, "HS256", map[string]interface{}{"sub": 2}, hs256)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, userID)
+
+	_, err = extractor.Extract(context.Background(), subject.HeaderCarrier{})
+	assert.ErrorIs(t, err, subject.ErrNoSubject)
+}
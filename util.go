@@ -55,3 +55,19 @@ func parseRolePermission(input string) (RolePermission, error) {
 
 	return rp, nil
 }
+
+// withoutStrings returns the values not in excluded, keeping their order.
+func withoutStrings(values, excluded []string) []string {
+	skip := make(map[string]bool, len(excluded))
+	for _, value := range excluded {
+		skip[value] = true
+	}
+
+	var result []string
+	for _, value := range values {
+		if !skip[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}